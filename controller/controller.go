@@ -121,6 +121,9 @@ type Controller struct {
 	ManagedRecordTypes []string
 }
 
+// defaultManagedRecordTypes are the record types managed when none are configured.
+var defaultManagedRecordTypes = []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}
+
 // RunOnce runs a single iteration of a reconciliation loop.
 func (c *Controller) RunOnce(ctx context.Context) error {
 	records, err := c.Registry.Records(ctx)
@@ -143,13 +146,18 @@ func (c *Controller) RunOnce(ctx context.Context) error {
 
 	endpoints = c.Registry.AdjustEndpoints(endpoints)
 
+	managedRecordTypes := c.ManagedRecordTypes
+	if len(managedRecordTypes) == 0 {
+		managedRecordTypes = defaultManagedRecordTypes
+	}
+
 	plan := &plan.Plan{
 		Policies:           []plan.Policy{c.Policy},
 		Current:            records,
 		Desired:            endpoints,
 		DomainFilter:       c.DomainFilter,
 		PropertyComparator: c.Registry.PropertyValuesEqual,
-		ManagedRecords:     []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		ManagedRecords:     managedRecordTypes,
 	}
 
 	plan = plan.Calculate()
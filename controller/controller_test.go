@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/external-dns/internal/testutils"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
 	"sigs.k8s.io/external-dns/registry"
 
 	"github.com/stretchr/testify/assert"
@@ -154,6 +155,98 @@ func TestRunOnce(t *testing.T) {
 	source.AssertExpectations(t)
 }
 
+// TestRunOnceManagedRecordTypes tests that RunOnce only plans changes for the managed record types.
+func TestRunOnceManagedRecordTypes(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{
+			DNSName:    "create-record",
+			RecordType: endpoint.RecordTypeAAAA,
+			Targets:    endpoint.Targets{"2001:db8::1"},
+		},
+		{
+			DNSName:    "ignored-record",
+			RecordType: endpoint.RecordTypeNS,
+			Targets:    endpoint.Targets{"ns1.example.org"},
+		},
+	}, nil)
+
+	provider := newMockProvider(
+		[]*endpoint.Endpoint{},
+		&plan.Changes{
+			Create: []*endpoint.Endpoint{
+				{DNSName: "create-record", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+			},
+		},
+	)
+
+	r, err := registry.NewNoopRegistry(provider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+	}
+
+	assert.NoError(t, ctrl.RunOnce(context.Background()))
+	source.AssertExpectations(t)
+}
+
+// TestRunOnceDualStack tests that the A and AAAA records of a name are both created and then left alone.
+func TestRunOnceDualStack(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		{
+			DNSName:    "dual.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Targets:    endpoint.Targets{"1.2.3.4"},
+		},
+		{
+			DNSName:    "dual.example.org",
+			RecordType: endpoint.RecordTypeAAAA,
+			Targets:    endpoint.Targets{"2001:db8::1"},
+		},
+	}, nil)
+
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone("example.org"))
+
+	var changes []*plan.Changes
+	p.OnApplyChanges = func(ctx context.Context, c *plan.Changes) {
+		changes = append(changes, c)
+	}
+
+	r, err := registry.NewTXTRegistry(p, "", "", "owner", 0, "", nil)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, changes, 1)
+	assert.True(t, testutils.SameEndpoints(changes[0].Create, []*endpoint.Endpoint{
+		{DNSName: "dual.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+		{DNSName: "dual.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+		{DNSName: "dual.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner\""}},
+		{DNSName: "aaaa-dual.example.org", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner\""}},
+	}))
+
+	// A second run with the same endpoints has nothing left to change.
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, changes, 2)
+	assert.Empty(t, changes[1].Create)
+	assert.Empty(t, changes[1].UpdateOld)
+	assert.Empty(t, changes[1].UpdateNew)
+	assert.Empty(t, changes[1].Delete)
+	source.AssertExpectations(t)
+}
+
 func TestShouldRunOnce(t *testing.T) {
 	ctrl := &Controller{Interval: 10 * time.Minute}
 
@@ -41,6 +41,16 @@ const (
 
 	// DualstackLabelKey is the name of the label that identifies dualstack endpoints
 	DualstackLabelKey = "dualstack"
+
+	// OwnerIDLabelKey is the name of the label that holds the owner suggested by the resource of an Endpoint,
+	// which the TXT registry manages the Endpoint with instead of its own owner if it is allowed
+	OwnerIDLabelKey = "owner-id"
+
+	// CreatePTRLabelKey is the name of the label that hints to create a PTR record for the targets of an Endpoint
+	CreatePTRLabelKey = "create-ptr"
+
+	// ClusterLabelKey is the name of the label that identifies the cluster an Endpoint was generated in
+	ClusterLabelKey = "cluster"
 )
 
 // Labels store metadata related to the endpoint
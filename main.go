@@ -24,6 +24,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -97,31 +98,65 @@ func main() {
 	go serveMetrics(cfg.MetricsAddress)
 	go handleSigterm(cancel)
 
+	recordTypeTTLOverrides, err := source.ParseRecordTypeTTLOverrides(cfg.RecordTypeTTLOverrides)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Create a source.Config from the flags passed by the user.
 	sourceCfg := &source.Config{
-		Namespace:                      cfg.Namespace,
-		AnnotationFilter:               cfg.AnnotationFilter,
-		LabelFilter:                    cfg.LabelFilter,
-		FQDNTemplate:                   cfg.FQDNTemplate,
-		CombineFQDNAndAnnotation:       cfg.CombineFQDNAndAnnotation,
-		IgnoreHostnameAnnotation:       cfg.IgnoreHostnameAnnotation,
-		IgnoreIngressTLSSpec:           cfg.IgnoreIngressTLSSpec,
-		Compatibility:                  cfg.Compatibility,
-		PublishInternal:                cfg.PublishInternal,
-		PublishHostIP:                  cfg.PublishHostIP,
-		AlwaysPublishNotReadyAddresses: cfg.AlwaysPublishNotReadyAddresses,
-		ConnectorServer:                cfg.ConnectorSourceServer,
-		CRDSourceAPIVersion:            cfg.CRDSourceAPIVersion,
-		CRDSourceKind:                  cfg.CRDSourceKind,
-		KubeConfig:                     cfg.KubeConfig,
-		APIServerURL:                   cfg.APIServerURL,
-		ServiceTypeFilter:              cfg.ServiceTypeFilter,
-		CFAPIEndpoint:                  cfg.CFAPIEndpoint,
-		CFUsername:                     cfg.CFUsername,
-		CFPassword:                     cfg.CFPassword,
-		ContourLoadBalancerService:     cfg.ContourLoadBalancerService,
-		SkipperRouteGroupVersion:       cfg.SkipperRouteGroupVersion,
-		RequestTimeout:                 cfg.RequestTimeout,
+		Namespace:                              cfg.Namespace,
+		AnnotationPrefix:                       cfg.AnnotationPrefix,
+		NamespaceFilter:                        source.NamespaceFilter{Allow: cfg.NamespaceAllow, Deny: cfg.NamespaceDeny},
+		AnnotationFilter:                       cfg.AnnotationFilter,
+		LabelFilter:                            cfg.LabelFilter,
+		FQDNTemplate:                           cfg.FQDNTemplate,
+		CombineFQDNAndAnnotation:               cfg.CombineFQDNAndAnnotation,
+		IgnoreHostnameAnnotation:               cfg.IgnoreHostnameAnnotation,
+		HostnameAnnotationStrategy:             cfg.HostnameAnnotationStrategy,
+		IgnoreIngressTLSSpec:                   cfg.IgnoreIngressTLSSpec,
+		Compatibility:                          cfg.Compatibility,
+		PublishInternal:                        cfg.PublishInternal,
+		PublishHostIP:                          cfg.PublishHostIP,
+		AlwaysPublishNotReadyAddresses:         cfg.AlwaysPublishNotReadyAddresses,
+		ConnectorServer:                        cfg.ConnectorSourceServer,
+		FileSourcePath:                         cfg.FileSourcePath,
+		CRDSourceAPIVersion:                    cfg.CRDSourceAPIVersion,
+		CRDSourceKind:                          cfg.CRDSourceKind,
+		KubeConfig:                             cfg.KubeConfig,
+		APIServerURL:                           cfg.APIServerURL,
+		ServiceTypeFilter:                      cfg.ServiceTypeFilter,
+		CFAPIEndpoint:                          cfg.CFAPIEndpoint,
+		CFUsername:                             cfg.CFUsername,
+		CFPassword:                             cfg.CFPassword,
+		ContourLoadBalancerService:             cfg.ContourLoadBalancerService,
+		ContourAcceptConditions:                cfg.ContourAcceptConditions,
+		ContourPublishInvalid:                  cfg.ContourPublishInvalid,
+		ContourNodePortTargets:                 cfg.ContourNodePortTargets,
+		ContourNodeAddressType:                 cfg.ContourNodeAddressType,
+		ContourRouteWeightsToDNS:               cfg.ContourRouteWeightsToDNS,
+		ContourFQDNSuffix:                      cfg.ContourFQDNSuffix,
+		ContourEmitWWWAlias:                    cfg.ContourEmitWWWAlias,
+		ContourIngressClass:                    cfg.ContourIngressClass,
+		TraefikLoadBalancerService:             cfg.TraefikLoadBalancerService,
+		SkipperRouteGroupVersion:               cfg.SkipperRouteGroupVersion,
+		RequestTimeout:                         cfg.RequestTimeout,
+		CacheSyncTimeout:                       cfg.CacheSyncTimeout,
+		TargetLookupRetries:                    cfg.TargetLookupRetries,
+		AmbassadorMergeServiceProviderSpecific: cfg.AmbassadorMergeServiceProviderSpecific,
+		RecordTypeTTLOverrides:                 recordTypeTTLOverrides,
+		PreferObjectTTL:                        cfg.PreferObjectTTL,
+		DefaultTTL:                             cfg.DefaultTTL,
+		MinTTL:                                 cfg.MinTTL,
+		MergeDuplicateEndpoints:                cfg.MergeDuplicateEndpoints,
+		EventDebounceInterval:                  cfg.EventDebounceInterval,
+		DomainFilter:                           cfg.SourceDomainFilter,
+		ExcludeDomains:                         cfg.SourceExcludeDomains,
+		ManagedRecordTypes:                     cfg.SourceManagedRecordTypes,
+		StripEndpointLabels:                    cfg.StripEndpointLabels,
+		CreatePTRHints:                         cfg.CreatePTRHints,
+		ClusterID:                              cfg.ClusterID,
+		MetricsRegisterer:                      prometheus.DefaultRegisterer,
 	}
 
 	// Lookup all the selected sources by names and pass them the desired configuration.
@@ -313,7 +348,7 @@ func main() {
 	case "noop":
 		r, err = registry.NewNoopRegistry(p)
 	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement)
+		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.TXTAllowedOwnerIDs)
 	case "aws-sd":
 		r, err = registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
 	default:
@@ -41,132 +41,166 @@ var (
 
 // Config is a project-wide configuration
 type Config struct {
-	APIServerURL                      string
-	KubeConfig                        string
-	RequestTimeout                    time.Duration
-	ContourLoadBalancerService        string
-	SkipperRouteGroupVersion          string
-	Sources                           []string
-	Namespace                         string
-	AnnotationFilter                  string
-	LabelFilter                       string
-	FQDNTemplate                      string
-	CombineFQDNAndAnnotation          bool
-	IgnoreHostnameAnnotation          bool
-	IgnoreIngressTLSSpec              bool
-	Compatibility                     string
-	PublishInternal                   bool
-	PublishHostIP                     bool
-	AlwaysPublishNotReadyAddresses    bool
-	ConnectorSourceServer             string
-	Provider                          string
-	GoogleProject                     string
-	GoogleBatchChangeSize             int
-	GoogleBatchChangeInterval         time.Duration
-	DomainFilter                      []string
-	ExcludeDomains                    []string
-	ZoneNameFilter                    []string
-	ZoneIDFilter                      []string
-	AlibabaCloudConfigFile            string
-	AlibabaCloudZoneType              string
-	AWSZoneType                       string
-	AWSZoneTagFilter                  []string
-	AWSAssumeRole                     string
-	AWSBatchChangeSize                int
-	AWSBatchChangeInterval            time.Duration
-	AWSEvaluateTargetHealth           bool
-	AWSAPIRetries                     int
-	AWSPreferCNAME                    bool
-	AWSZoneCacheDuration              time.Duration
-	AzureConfigFile                   string
-	AzureResourceGroup                string
-	AzureSubscriptionID               string
-	AzureUserAssignedIdentityClientID string
-	CloudflareProxied                 bool
-	CloudflareZonesPerPage            int
-	CoreDNSPrefix                     string
-	RcodezeroTXTEncrypt               bool
-	AkamaiServiceConsumerDomain       string
-	AkamaiClientToken                 string
-	AkamaiClientSecret                string
-	AkamaiAccessToken                 string
-	AkamaiEdgercPath                  string
-	AkamaiEdgercSection               string
-	InfobloxGridHost                  string
-	InfobloxWapiPort                  int
-	InfobloxWapiUsername              string
-	InfobloxWapiPassword              string `secure:"yes"`
-	InfobloxWapiVersion               string
-	InfobloxSSLVerify                 bool
-	InfobloxView                      string
-	InfobloxMaxResults                int
-	DynCustomerName                   string
-	DynUsername                       string
-	DynPassword                       string `secure:"yes"`
-	DynMinTTLSeconds                  int
-	OCIConfigFile                     string
-	InMemoryZones                     []string
-	OVHEndpoint                       string
-	OVHApiRateLimit                   int
-	PDNSServer                        string
-	PDNSAPIKey                        string `secure:"yes"`
-	PDNSTLSEnabled                    bool
-	TLSCA                             string
-	TLSClientCert                     string
-	TLSClientCertKey                  string
-	Policy                            string
-	Registry                          string
-	TXTOwnerID                        string
-	TXTPrefix                         string
-	TXTSuffix                         string
-	Interval                          time.Duration
-	Once                              bool
-	DryRun                            bool
-	UpdateEvents                      bool
-	LogFormat                         string
-	MetricsAddress                    string
-	LogLevel                          string
-	TXTCacheInterval                  time.Duration
-	TXTWildcardReplacement            string
-	ExoscaleEndpoint                  string
-	ExoscaleAPIKey                    string `secure:"yes"`
-	ExoscaleAPISecret                 string `secure:"yes"`
-	CRDSourceAPIVersion               string
-	CRDSourceKind                     string
-	ServiceTypeFilter                 []string
-	CFAPIEndpoint                     string
-	CFUsername                        string
-	CFPassword                        string
-	RFC2136Host                       string
-	RFC2136Port                       int
-	RFC2136Zone                       string
-	RFC2136Insecure                   bool
-	RFC2136GSSTSIG                    bool
-	RFC2136KerberosUsername           string
-	RFC2136KerberosPassword           string
-	RFC2136TSIGKeyName                string
-	RFC2136TSIGSecret                 string `secure:"yes"`
-	RFC2136TSIGSecretAlg              string
-	RFC2136TAXFR                      bool
-	RFC2136MinTTL                     time.Duration
-	NS1Endpoint                       string
-	NS1IgnoreSSL                      bool
-	NS1MinTTLSeconds                  int
-	TransIPAccountName                string
-	TransIPPrivateKeyFile             string
-	DigitalOceanAPIPageSize           int
-	ManagedDNSRecordTypes             []string
-	GoDaddyAPIKey                     string `secure:"yes"`
-	GoDaddySecretKey                  string `secure:"yes"`
-	GoDaddyTTL                        int64
-	GoDaddyOTE                        bool
+	APIServerURL                           string
+	KubeConfig                             string
+	RequestTimeout                         time.Duration
+	CacheSyncTimeout                       time.Duration
+	TargetLookupRetries                    int
+	ContourLoadBalancerService             string
+	ContourAcceptConditions                bool
+	ContourPublishInvalid                  bool
+	ContourNodePortTargets                 bool
+	ContourNodeAddressType                 string
+	ContourRouteWeightsToDNS               bool
+	ContourFQDNSuffix                      string
+	ContourEmitWWWAlias                    bool
+	ContourIngressClass                    string
+	TraefikLoadBalancerService             string
+	AmbassadorMergeServiceProviderSpecific bool
+	SkipperRouteGroupVersion               string
+	Sources                                []string
+	Namespace                              string
+	AnnotationPrefix                       string
+	NamespaceAllow                         []string
+	NamespaceDeny                          []string
+	AnnotationFilter                       string
+	LabelFilter                            string
+	FQDNTemplate                           string
+	CombineFQDNAndAnnotation               bool
+	IgnoreHostnameAnnotation               bool
+	HostnameAnnotationStrategy             string
+	IgnoreIngressTLSSpec                   bool
+	Compatibility                          string
+	PublishInternal                        bool
+	PublishHostIP                          bool
+	AlwaysPublishNotReadyAddresses         bool
+	ConnectorSourceServer                  string
+	FileSourcePath                         string
+	Provider                               string
+	GoogleProject                          string
+	GoogleBatchChangeSize                  int
+	GoogleBatchChangeInterval              time.Duration
+	DomainFilter                           []string
+	ExcludeDomains                         []string
+	ZoneNameFilter                         []string
+	ZoneIDFilter                           []string
+	AlibabaCloudConfigFile                 string
+	AlibabaCloudZoneType                   string
+	AWSZoneType                            string
+	AWSZoneTagFilter                       []string
+	AWSAssumeRole                          string
+	AWSBatchChangeSize                     int
+	AWSBatchChangeInterval                 time.Duration
+	AWSEvaluateTargetHealth                bool
+	AWSAPIRetries                          int
+	AWSPreferCNAME                         bool
+	AWSZoneCacheDuration                   time.Duration
+	AzureConfigFile                        string
+	AzureResourceGroup                     string
+	AzureSubscriptionID                    string
+	AzureUserAssignedIdentityClientID      string
+	CloudflareProxied                      bool
+	CloudflareZonesPerPage                 int
+	CoreDNSPrefix                          string
+	RcodezeroTXTEncrypt                    bool
+	AkamaiServiceConsumerDomain            string
+	AkamaiClientToken                      string
+	AkamaiClientSecret                     string
+	AkamaiAccessToken                      string
+	AkamaiEdgercPath                       string
+	AkamaiEdgercSection                    string
+	InfobloxGridHost                       string
+	InfobloxWapiPort                       int
+	InfobloxWapiUsername                   string
+	InfobloxWapiPassword                   string `secure:"yes"`
+	InfobloxWapiVersion                    string
+	InfobloxSSLVerify                      bool
+	InfobloxView                           string
+	InfobloxMaxResults                     int
+	DynCustomerName                        string
+	DynUsername                            string
+	DynPassword                            string `secure:"yes"`
+	DynMinTTLSeconds                       int
+	OCIConfigFile                          string
+	InMemoryZones                          []string
+	OVHEndpoint                            string
+	OVHApiRateLimit                        int
+	PDNSServer                             string
+	PDNSAPIKey                             string `secure:"yes"`
+	PDNSTLSEnabled                         bool
+	TLSCA                                  string
+	TLSClientCert                          string
+	TLSClientCertKey                       string
+	Policy                                 string
+	Registry                               string
+	TXTOwnerID                             string
+	TXTPrefix                              string
+	TXTSuffix                              string
+	Interval                               time.Duration
+	Once                                   bool
+	DryRun                                 bool
+	UpdateEvents                           bool
+	EventDebounceInterval                  time.Duration
+	LogFormat                              string
+	MetricsAddress                         string
+	LogLevel                               string
+	TXTCacheInterval                       time.Duration
+	TXTWildcardReplacement                 string
+	TXTAllowedOwnerIDs                     []string
+	ExoscaleEndpoint                       string
+	ExoscaleAPIKey                         string `secure:"yes"`
+	ExoscaleAPISecret                      string `secure:"yes"`
+	CRDSourceAPIVersion                    string
+	CRDSourceKind                          string
+	ServiceTypeFilter                      []string
+	CFAPIEndpoint                          string
+	CFUsername                             string
+	CFPassword                             string
+	RFC2136Host                            string
+	RFC2136Port                            int
+	RFC2136Zone                            string
+	RFC2136Insecure                        bool
+	RFC2136GSSTSIG                         bool
+	RFC2136KerberosUsername                string
+	RFC2136KerberosPassword                string
+	RFC2136TSIGKeyName                     string
+	RFC2136TSIGSecret                      string `secure:"yes"`
+	RFC2136TSIGSecretAlg                   string
+	RFC2136TAXFR                           bool
+	RFC2136MinTTL                          time.Duration
+	NS1Endpoint                            string
+	NS1IgnoreSSL                           bool
+	NS1MinTTLSeconds                       int
+	TransIPAccountName                     string
+	TransIPPrivateKeyFile                  string
+	DigitalOceanAPIPageSize                int
+	ManagedDNSRecordTypes                  []string
+	RecordTypeTTLOverrides                 []string
+	PreferObjectTTL                        bool
+	DefaultTTL                             time.Duration
+	MinTTL                                 time.Duration
+	MergeDuplicateEndpoints                bool
+	SourceDomainFilter                     []string
+	SourceExcludeDomains                   []string
+	SourceManagedRecordTypes               []string
+	StripEndpointLabels                    []string
+	CreatePTRHints                         bool
+	ClusterID                              string
+	GoDaddyAPIKey                          string `secure:"yes"`
+	GoDaddySecretKey                       string `secure:"yes"`
+	GoDaddyTTL                             int64
+	GoDaddyOTE                             bool
 }
 
 var defaultConfig = &Config{
 	APIServerURL:                "",
 	KubeConfig:                  "",
 	RequestTimeout:              time.Second * 30,
+	CacheSyncTimeout:            time.Second * 60,
+	TargetLookupRetries:         3,
 	ContourLoadBalancerService:  "heptio-contour/contour",
+	TraefikLoadBalancerService:  "traefik/traefik",
+	ContourNodeAddressType:      "ExternalIP",
 	SkipperRouteGroupVersion:    "zalando.org/v1",
 	Sources:                     nil,
 	Namespace:                   "",
@@ -175,6 +209,8 @@ var defaultConfig = &Config{
 	FQDNTemplate:                "",
 	CombineFQDNAndAnnotation:    false,
 	IgnoreHostnameAnnotation:    false,
+	HostnameAnnotationStrategy:  "append",
+	AnnotationPrefix:            "external-dns.alpha.kubernetes.io/",
 	IgnoreIngressTLSSpec:        false,
 	Compatibility:               "",
 	PublishInternal:             false,
@@ -238,6 +274,7 @@ var defaultConfig = &Config{
 	Once:                        false,
 	DryRun:                      false,
 	UpdateEvents:                false,
+	EventDebounceInterval:       0,
 	LogFormat:                   "text",
 	MetricsAddress:              ":7979",
 	LogLevel:                    logrus.InfoLevel.String(),
@@ -319,6 +356,8 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("server", "The Kubernetes API server to connect to (default: auto-detect)").Default(defaultConfig.APIServerURL).StringVar(&cfg.APIServerURL)
 	app.Flag("kubeconfig", "Retrieve target cluster configuration from a Kubernetes configuration file (default: auto-detect)").Default(defaultConfig.KubeConfig).StringVar(&cfg.KubeConfig)
 	app.Flag("request-timeout", "Request timeout when calling Kubernetes APIs. 0s means no timeout").Default(defaultConfig.RequestTimeout.String()).DurationVar(&cfg.RequestTimeout)
+	app.Flag("cache-sync-timeout", "The time sources wait for their caches of Kubernetes objects to be populated on startup").Default(defaultConfig.CacheSyncTimeout.String()).DurationVar(&cfg.CacheSyncTimeout)
+	app.Flag("target-lookup-retries", "The number of times sources retry transient errors when looking up the load balancer service of their targets").Default(strconv.Itoa(defaultConfig.TargetLookupRetries)).IntVar(&cfg.TargetLookupRetries)
 
 	// Flags related to cloud foundry
 	app.Flag("cf-api-endpoint", "The fully-qualified domain name of the cloud foundry instance you are targeting").Default(defaultConfig.CFAPIEndpoint).StringVar(&cfg.CFAPIEndpoint)
@@ -326,30 +365,58 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("cf-password", "The password to log into the cloud foundry API").Default(defaultConfig.CFPassword).StringVar(&cfg.CFPassword)
 
 	// Flags related to Contour
-	app.Flag("contour-load-balancer", "The fully-qualified name of the Contour load balancer service. (default: heptio-contour/contour)").Default("heptio-contour/contour").StringVar(&cfg.ContourLoadBalancerService)
+	app.Flag("contour-load-balancer", "Comma-separated names of the Contour load balancer services as namespace/name, or bare names in the source namespace. (default: heptio-contour/contour)").Default("heptio-contour/contour").StringVar(&cfg.ContourLoadBalancerService)
+	app.Flag("traefik-load-balancer", "The fully-qualified name of the Traefik load balancer service. (default: traefik/traefik)").Default(defaultConfig.TraefikLoadBalancerService).StringVar(&cfg.TraefikLoadBalancerService)
+	app.Flag("contour-accept-conditions", "Also consider Contour HTTPProxies valid when their status conditions contain a Valid condition with status True (default: disabled)").BoolVar(&cfg.ContourAcceptConditions)
+	app.Flag("contour-publish-invalid", "Publish records for Contour IngressRoutes and HTTPProxies regardless of their status (default: disabled)").BoolVar(&cfg.ContourPublishInvalid)
+	app.Flag("contour-node-port-targets", "Use the addresses of the nodes backing a NodePort Contour load balancer service as targets of IngressRoutes (default: disabled)").BoolVar(&cfg.ContourNodePortTargets)
+	app.Flag("contour-node-address-type", "The preferred type of node addresses used as targets with --contour-node-port-targets; the other type is used if no node has an address of this type (default: ExternalIP, options: ExternalIP, InternalIP)").Default(defaultConfig.ContourNodeAddressType).EnumVar(&cfg.ContourNodeAddressType, "ExternalIP", "InternalIP")
+	app.Flag("contour-route-weights-to-dns", "Publish a weighted record pointing at the load balancer of each upstream service of the routes of Contour HTTPProxies which weight their services (default: disabled)").BoolVar(&cfg.ContourRouteWeightsToDNS)
+	app.Flag("contour-fqdn-suffix", "The domain appended to the virtual host fqdns of Contour HTTPProxies and IngressRoutes without any dot, e.g. app becomes app.example.com with example.com (default: none)").StringVar(&cfg.ContourFQDNSuffix)
+	app.Flag("contour-emit-www-alias", "Also publish the www. alias of each apex virtual host fqdn, e.g. www.example.com for example.com, of Contour HTTPProxies and IngressRoutes with the same targets (default: disabled)").BoolVar(&cfg.ContourEmitWWWAlias)
+	app.Flag("contour-ingress-class", "Skip Contour HTTPProxies of another ingress class, declared by spec.ingressClassName or the projectcontour.io/ingress.class or contour.heptio.com/ingress.class annotation; HTTPProxies without a class are kept (default: all classes)").StringVar(&cfg.ContourIngressClass)
+
+	// Flags related to Ambassador
+	app.Flag("ambassador-merge-service-provider-specific", "Merge the provider-specific annotations of the Ambassador load balancer service into the endpoints of its Hosts; annotations on the Host take precedence (default: disabled)").BoolVar(&cfg.AmbassadorMergeServiceProviderSpecific)
 
 	// Flags related to Skipper RouteGroup
 	app.Flag("skipper-routegroup-groupversion", "The resource version for skipper routegroup").Default(source.DefaultRoutegroupVersion).StringVar(&cfg.SkipperRouteGroupVersion)
 
 	// Flags related to processing sources
-	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, ingress, node, fake, connector, istio-gateway, istio-virtualservice, cloudfoundry, contour-ingressroute, contour-httpproxy, crd, empty, skipper-routegroup, openshift-route, ambassador-host)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "ingress", "node", "istio-gateway", "istio-virtualservice", "cloudfoundry", "contour-ingressroute", "contour-httpproxy", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route", "ambassador-host")
+	app.Flag("source", "The resource types that are queried for endpoints; specify multiple times for multiple sources (required, options: service, endpointslice, ingress, node, fake, connector, istio-gateway, istio-virtualservice, cloudfoundry, contour-ingressroute, contour-httpproxy, crd, empty, skipper-routegroup, openshift-route, ambassador-host, traefik-ingressroute, file)").Required().PlaceHolder("source").EnumsVar(&cfg.Sources, "service", "endpointslice", "ingress", "node", "istio-gateway", "istio-virtualservice", "cloudfoundry", "contour-ingressroute", "contour-httpproxy", "traefik-ingressroute", "fake", "connector", "crd", "empty", "skipper-routegroup", "openshift-route", "ambassador-host", "file")
 
 	app.Flag("namespace", "Limit sources of endpoints to a specific namespace (default: all namespaces)").Default(defaultConfig.Namespace).StringVar(&cfg.Namespace)
+	app.Flag("namespace-allow", "Only publish resources of namespaces matching this glob, e.g. team-*; specify multiple times for multiple patterns (default: all namespaces)").StringsVar(&cfg.NamespaceAllow)
+	app.Flag("namespace-deny", "Never publish resources of namespaces matching this glob, e.g. kube-system; specify multiple times for multiple patterns; requires watching all namespaces").StringsVar(&cfg.NamespaceDeny)
 	app.Flag("annotation-filter", "Filter sources managed by external-dns via annotation using label selector semantics (default: all sources)").Default(defaultConfig.AnnotationFilter).StringVar(&cfg.AnnotationFilter)
-	app.Flag("label-filter", "Filter sources managed by external-dns via label selector when listing all resources; currently only supported by source CRD").Default(defaultConfig.LabelFilter).StringVar(&cfg.LabelFilter)
+	app.Flag("label-filter", "Filter sources managed by external-dns via label selector when listing all resources; currently only supported by sources CRD, contour-ingressroute and contour-httpproxy").Default(defaultConfig.LabelFilter).StringVar(&cfg.LabelFilter)
 	app.Flag("fqdn-template", "A templated string that's used to generate DNS names from sources that don't define a hostname themselves, or to add a hostname suffix when paired with the fake source (optional). Accepts comma separated list for multiple global FQDN.").Default(defaultConfig.FQDNTemplate).StringVar(&cfg.FQDNTemplate)
 	app.Flag("combine-fqdn-annotation", "Combine FQDN template and Annotations instead of overwriting").BoolVar(&cfg.CombineFQDNAndAnnotation)
 	app.Flag("ignore-hostname-annotation", "Ignore hostname annotation when generating DNS names, valid only when using fqdn-template is set (optional, default: false)").BoolVar(&cfg.IgnoreHostnameAnnotation)
+	app.Flag("annotation-prefix", "The prefix of the keys of the annotations read by sources, e.g. to run beside another deployment; annotations of the default prefix are ignored if another one is used (default: external-dns.alpha.kubernetes.io/)").Default(defaultConfig.AnnotationPrefix).StringVar(&cfg.AnnotationPrefix)
+	app.Flag("hostname-annotation-strategy", "Whether the hostname annotation is published along with the virtual host fqdn or replaces it; currently only supported by sources contour-ingressroute and contour-httpproxy (default: append, options: append, replace)").Default(defaultConfig.HostnameAnnotationStrategy).EnumVar(&cfg.HostnameAnnotationStrategy, "append", "replace")
 	app.Flag("ignore-ingress-tls-spec", "Ignore tls spec section in ingresses resources, applicable only for ingress sources (optional, default: false)").BoolVar(&cfg.IgnoreIngressTLSSpec)
 	app.Flag("compatibility", "Process annotation semantics from legacy implementations (optional, options: mate, molecule)").Default(defaultConfig.Compatibility).EnumVar(&cfg.Compatibility, "", "mate", "molecule")
 	app.Flag("publish-internal-services", "Allow external-dns to publish DNS records for ClusterIP services (optional)").BoolVar(&cfg.PublishInternal)
 	app.Flag("publish-host-ip", "Allow external-dns to publish host-ip for headless services (optional)").BoolVar(&cfg.PublishHostIP)
 	app.Flag("always-publish-not-ready-addresses", "Always publish also not ready addresses for headless services (optional)").BoolVar(&cfg.AlwaysPublishNotReadyAddresses)
 	app.Flag("connector-source-server", "The server to connect for connector source, valid only when using connector source").Default(defaultConfig.ConnectorSourceServer).StringVar(&cfg.ConnectorSourceServer)
+	app.Flag("file-source-path", "The path of a YAML or JSON file with a list of endpoints, valid only when using file source").StringVar(&cfg.FileSourcePath)
 	app.Flag("crd-source-apiversion", "API version of the CRD for crd source, e.g. `externaldns.k8s.io/v1alpha1`, valid only when using crd source").Default(defaultConfig.CRDSourceAPIVersion).StringVar(&cfg.CRDSourceAPIVersion)
 	app.Flag("crd-source-kind", "Kind of the CRD for the crd source in API group and version specified by crd-source-apiversion").Default(defaultConfig.CRDSourceKind).StringVar(&cfg.CRDSourceKind)
 	app.Flag("service-type-filter", "The service types to take care about (default: all, expected: ClusterIP, NodePort, LoadBalancer or ExternalName)").StringsVar(&cfg.ServiceTypeFilter)
-	app.Flag("managed-record-types", "Comma separated list of record types to manage (default: A, CNAME) (supported records: CNAME, A, NS").Default("A", "CNAME").StringsVar(&cfg.ManagedDNSRecordTypes)
+	app.Flag("record-type-ttl-override", "Override the TTL of records of a type, e.g. AAAA=60s; specify multiple times for multiple record types (optional)").StringsVar(&cfg.RecordTypeTTLOverrides)
+	app.Flag("prefer-object-ttl", "Only apply record type TTL overrides to records without a TTL annotation (default: disabled)").BoolVar(&cfg.PreferObjectTTL)
+	app.Flag("default-ttl", "The TTL of records without a TTL annotation; 0s leaves the TTL to the provider (default: 0s)").Default(defaultConfig.DefaultTTL.String()).DurationVar(&cfg.DefaultTTL)
+	app.Flag("min-ttl", "The minimum TTL of records; lower TTLs are raised to it, while records without a TTL are left to the provider; 0s disables it (default: 0s)").Default(defaultConfig.MinTTL.String()).DurationVar(&cfg.MinTTL)
+	app.Flag("merge-duplicate-endpoints", "Merge the endpoints of a source with the same name, record type and set identifier into one with the union of their targets (default: disabled)").BoolVar(&cfg.MergeDuplicateEndpoints)
+	app.Flag("source-domain-filter", "Only publish records of sources in a domain, regardless of the target zones; specify multiple times for multiple domains (optional)").StringsVar(&cfg.SourceDomainFilter)
+	app.Flag("source-exclude-domains", "Never publish records of sources in a subdomain, regardless of the target zones; specify multiple times for multiple domains (optional)").StringsVar(&cfg.SourceExcludeDomains)
+	app.Flag("source-managed-record-types", "Only publish records of sources of a type, e.g. CNAME; specify multiple times for multiple record types (default: all types)").StringsVar(&cfg.SourceManagedRecordTypes)
+	app.Flag("strip-endpoint-label", "Remove this label key, e.g. resource, from the endpoints of sources; specify multiple times for multiple keys").StringsVar(&cfg.StripEndpointLabels)
+	app.Flag("create-ptr-hints", "Label the A and AAAA endpoints of sources so that PTR records can be created for their targets (default: disabled)").BoolVar(&cfg.CreatePTRHints)
+	app.Flag("cluster-id", "Label the endpoints of sources with this cluster ID, so that registries can partition ownership by cluster (optional)").StringVar(&cfg.ClusterID)
+	app.Flag("managed-record-types", "Comma separated list of record types to manage; add AAAA to publish IPv6 targets (default: A, CNAME) (supported records: CNAME, A, AAAA, NS").Default("A", "CNAME").StringsVar(&cfg.ManagedDNSRecordTypes)
 
 	// Flags related to providers
 	app.Flag("provider", "The DNS provider where the DNS records will be created (required, options: aws, aws-sd, godaddy, google, azure, azure-dns, azure-private-dns, cloudflare, rcodezero, digitalocean, hetzner, dnsimple, akamai, infoblox, dyn, designate, coredns, skydns, inmemory, ovh, pdns, oci, exoscale, linode, rfc2136, ns1, transip, vinyldns, rdns, scaleway, vultr, ultradns)").Required().PlaceHolder("provider").EnumVar(&cfg.Provider, "aws", "aws-sd", "google", "azure", "azure-dns", "hetzner", "azure-private-dns", "alibabacloud", "cloudflare", "rcodezero", "digitalocean", "dnsimple", "akamai", "infoblox", "dyn", "designate", "coredns", "skydns", "inmemory", "ovh", "pdns", "oci", "exoscale", "linode", "rfc2136", "ns1", "transip", "vinyldns", "rdns", "scaleway", "vultr", "ultradns", "godaddy")
@@ -450,6 +517,7 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("txt-prefix", "When using the TXT registry, a custom string that's prefixed to each ownership DNS record (optional). Mutual exclusive with txt-suffix!").Default(defaultConfig.TXTPrefix).StringVar(&cfg.TXTPrefix)
 	app.Flag("txt-suffix", "When using the TXT registry, a custom string that's suffixed to the host portion of each ownership DNS record (optional). Mutual exclusive with txt-prefix!").Default(defaultConfig.TXTSuffix).StringVar(&cfg.TXTSuffix)
 	app.Flag("txt-wildcard-replacement", "When using the TXT registry, a custom string that's used instead of an asterisk for TXT records corresponding to wildcard DNS records (optional)").Default(defaultConfig.TXTWildcardReplacement).StringVar(&cfg.TXTWildcardReplacement)
+	app.Flag("txt-allowed-owner-id", "When using the TXT registry, an owner id that resources may request for their records with the owner-id annotation; specify multiple times for multiple owner ids (default: none)").StringsVar(&cfg.TXTAllowedOwnerIDs)
 
 	// Flags related to the main control loop
 	app.Flag("txt-cache-interval", "The interval between cache synchronizations in duration format (default: disabled)").Default(defaultConfig.TXTCacheInterval.String()).DurationVar(&cfg.TXTCacheInterval)
@@ -457,6 +525,7 @@ func (cfg *Config) ParseFlags(args []string) error {
 	app.Flag("once", "When enabled, exits the synchronization loop after the first iteration (default: disabled)").BoolVar(&cfg.Once)
 	app.Flag("dry-run", "When enabled, prints DNS record changes rather than actually performing them (default: disabled)").BoolVar(&cfg.DryRun)
 	app.Flag("events", "When enabled, in addition to running every interval, the reconciliation loop will get triggered when supported sources change (default: disabled)").BoolVar(&cfg.UpdateEvents)
+	app.Flag("event-debounce-interval", "The time a source waits after a change before triggering the reconciliation loop, so that a burst of changes triggers it only once; 0s triggers it on every change (default: 0s)").Default(defaultConfig.EventDebounceInterval.String()).DurationVar(&cfg.EventDebounceInterval)
 
 	// Miscellaneous flags
 	app.Flag("log-format", "The format in which log messages are printed (default: text, options: text, json)").Default(defaultConfig.LogFormat).EnumVar(&cfg.LogFormat, "text", "json")
@@ -34,11 +34,17 @@ var (
 		APIServerURL:                "",
 		KubeConfig:                  "",
 		RequestTimeout:              time.Second * 30,
+		CacheSyncTimeout:            time.Second * 60,
+		TargetLookupRetries:         3,
 		ContourLoadBalancerService:  "heptio-contour/contour",
+		TraefikLoadBalancerService:  "traefik/traefik",
+		ContourNodeAddressType:      "ExternalIP",
 		SkipperRouteGroupVersion:    "zalando.org/v1",
 		Sources:                     []string{"service"},
 		Namespace:                   "",
 		FQDNTemplate:                "",
+		HostnameAnnotationStrategy:  "append",
+		AnnotationPrefix:            "external-dns.alpha.kubernetes.io/",
 		Compatibility:               "",
 		Provider:                    "google",
 		GoogleProject:               "",
@@ -93,6 +99,7 @@ var (
 		Once:                        false,
 		DryRun:                      false,
 		UpdateEvents:                false,
+		EventDebounceInterval:       0,
 		LogFormat:                   "text",
 		MetricsAddress:              ":7979",
 		LogLevel:                    logrus.InfoLevel.String(),
@@ -113,11 +120,22 @@ var (
 		APIServerURL:                "http://127.0.0.1:8080",
 		KubeConfig:                  "/some/path",
 		RequestTimeout:              time.Second * 77,
+		CacheSyncTimeout:            time.Second * 90,
+		TargetLookupRetries:         5,
 		ContourLoadBalancerService:  "heptio-contour-other/contour-other",
+		TraefikLoadBalancerService:  "traefik-other/traefik-other",
+		ContourNodePortTargets:      true,
+		ContourNodeAddressType:      "InternalIP",
+		ContourRouteWeightsToDNS:    true,
+		ContourFQDNSuffix:           "example.com",
+		ContourEmitWWWAlias:         true,
+		ContourIngressClass:         "contour",
 		SkipperRouteGroupVersion:    "zalando.org/v2",
 		Sources:                     []string{"service", "ingress", "connector"},
 		Namespace:                   "namespace",
 		IgnoreHostnameAnnotation:    true,
+		HostnameAnnotationStrategy:  "replace",
+		AnnotationPrefix:            "dns.example.com/",
 		IgnoreIngressTLSSpec:        true,
 		FQDNTemplate:                "{{.Name}}.service.example.com",
 		Compatibility:               "mate",
@@ -178,10 +196,12 @@ var (
 		Once:                        true,
 		DryRun:                      true,
 		UpdateEvents:                true,
+		EventDebounceInterval:       5 * time.Second,
 		LogFormat:                   "json",
 		MetricsAddress:              "127.0.0.1:9099",
 		LogLevel:                    logrus.DebugLevel.String(),
 		ConnectorSourceServer:       "localhost:8081",
+		FileSourcePath:              "/etc/external-dns/endpoints.yaml",
 		ExoscaleEndpoint:            "https://api.foo.ch/dns",
 		ExoscaleAPIKey:              "1",
 		ExoscaleAPISecret:           "2",
@@ -219,7 +239,16 @@ func TestParseFlags(t *testing.T) {
 				"--server=http://127.0.0.1:8080",
 				"--kubeconfig=/some/path",
 				"--request-timeout=77s",
+				"--cache-sync-timeout=90s",
+				"--target-lookup-retries=5",
 				"--contour-load-balancer=heptio-contour-other/contour-other",
+				"--traefik-load-balancer=traefik-other/traefik-other",
+				"--contour-node-port-targets",
+				"--contour-node-address-type=InternalIP",
+				"--contour-route-weights-to-dns",
+				"--contour-fqdn-suffix=example.com",
+				"--contour-emit-www-alias",
+				"--contour-ingress-class=contour",
 				"--skipper-routegroup-groupversion=zalando.org/v2",
 				"--source=service",
 				"--source=ingress",
@@ -227,6 +256,8 @@ func TestParseFlags(t *testing.T) {
 				"--namespace=namespace",
 				"--fqdn-template={{.Name}}.service.example.com",
 				"--ignore-hostname-annotation",
+				"--hostname-annotation-strategy=replace",
+				"--annotation-prefix=dns.example.com/",
 				"--ignore-ingress-tls-spec",
 				"--compatibility=mate",
 				"--provider=google",
@@ -290,10 +321,12 @@ func TestParseFlags(t *testing.T) {
 				"--once",
 				"--dry-run",
 				"--events",
+				"--event-debounce-interval=5s",
 				"--log-format=json",
 				"--metrics-address=127.0.0.1:9099",
 				"--log-level=debug",
 				"--connector-source-server=localhost:8081",
+				"--file-source-path=/etc/external-dns/endpoints.yaml",
 				"--exoscale-endpoint=https://api.foo.ch/dns",
 				"--exoscale-apikey=1",
 				"--exoscale-apisecret=2",
@@ -316,12 +349,23 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_SERVER":                          "http://127.0.0.1:8080",
 				"EXTERNAL_DNS_KUBECONFIG":                      "/some/path",
 				"EXTERNAL_DNS_REQUEST_TIMEOUT":                 "77s",
+				"EXTERNAL_DNS_CACHE_SYNC_TIMEOUT":              "90s",
+				"EXTERNAL_DNS_TARGET_LOOKUP_RETRIES":           "5",
 				"EXTERNAL_DNS_CONTOUR_LOAD_BALANCER":           "heptio-contour-other/contour-other",
+				"EXTERNAL_DNS_TRAEFIK_LOAD_BALANCER":           "traefik-other/traefik-other",
+				"EXTERNAL_DNS_CONTOUR_NODE_PORT_TARGETS":       "1",
+				"EXTERNAL_DNS_CONTOUR_NODE_ADDRESS_TYPE":       "InternalIP",
+				"EXTERNAL_DNS_CONTOUR_ROUTE_WEIGHTS_TO_DNS":    "1",
+				"EXTERNAL_DNS_CONTOUR_FQDN_SUFFIX":             "example.com",
+				"EXTERNAL_DNS_CONTOUR_EMIT_WWW_ALIAS":          "1",
+				"EXTERNAL_DNS_CONTOUR_INGRESS_CLASS":           "contour",
 				"EXTERNAL_DNS_SKIPPER_ROUTEGROUP_GROUPVERSION": "zalando.org/v2",
 				"EXTERNAL_DNS_SOURCE":                          "service\ningress\nconnector",
 				"EXTERNAL_DNS_NAMESPACE":                       "namespace",
 				"EXTERNAL_DNS_FQDN_TEMPLATE":                   "{{.Name}}.service.example.com",
 				"EXTERNAL_DNS_IGNORE_HOSTNAME_ANNOTATION":      "1",
+				"EXTERNAL_DNS_HOSTNAME_ANNOTATION_STRATEGY":    "replace",
+				"EXTERNAL_DNS_ANNOTATION_PREFIX":               "dns.example.com/",
 				"EXTERNAL_DNS_IGNORE_INGRESS_TLS_SPEC":         "1",
 				"EXTERNAL_DNS_COMPATIBILITY":                   "mate",
 				"EXTERNAL_DNS_PROVIDER":                        "google",
@@ -381,10 +425,12 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_ONCE":                            "1",
 				"EXTERNAL_DNS_DRY_RUN":                         "1",
 				"EXTERNAL_DNS_EVENTS":                          "1",
+				"EXTERNAL_DNS_EVENT_DEBOUNCE_INTERVAL":         "5s",
 				"EXTERNAL_DNS_LOG_FORMAT":                      "json",
 				"EXTERNAL_DNS_METRICS_ADDRESS":                 "127.0.0.1:9099",
 				"EXTERNAL_DNS_LOG_LEVEL":                       "debug",
 				"EXTERNAL_DNS_CONNECTOR_SOURCE_SERVER":         "localhost:8081",
+				"EXTERNAL_DNS_FILE_SOURCE_PATH":                "/etc/external-dns/endpoints.yaml",
 				"EXTERNAL_DNS_EXOSCALE_ENDPOINT":               "https://api.foo.ch/dns",
 				"EXTERNAL_DNS_EXOSCALE_APIKEY":                 "1",
 				"EXTERNAL_DNS_EXOSCALE_APISECRET":              "2",
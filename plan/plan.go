@@ -61,6 +61,8 @@ type Changes struct {
 
 // planTable is a supplementary struct for Plan
 // each row correspond to a dnsName -> (current record + all desired records)
+// Within a dnsName, rows are keyed by set identifier and by planRecordType, so that
+// AAAA records don't compete with the A or CNAME records of the same name.
 /*
 planTable: (-> = target)
 --------------------------------------------------------
@@ -73,12 +75,31 @@ bar.com |                | [->191.1.1.1, ->190.1.1.1]  |  = create (bar.com -> 1
 "=", i.e. result of calculation relies on supplied ConflictResolver
 */
 type planTable struct {
-	rows     map[string]map[string]*planTableRow
+	rows     map[string]map[planTableKey]*planTableRow
 	resolver ConflictResolver
 }
 
 func newPlanTable() planTable { //TODO: make resolver configurable
-	return planTable{map[string]map[string]*planTableRow{}, PerResource{}}
+	return planTable{map[string]map[planTableKey]*planTableRow{}, PerResource{}}
+}
+
+// planTableKey identifies the row of a record among the rows of its dnsName.
+type planTableKey struct {
+	setIdentifier string
+	recordType    string
+}
+
+func newPlanTableKey(e *endpoint.Endpoint) planTableKey {
+	return planTableKey{setIdentifier: e.SetIdentifier, recordType: planRecordType(e.RecordType)}
+}
+
+// planRecordType returns the record type a record is planned by. AAAA records
+// are planned on their own, while the other record types may replace each other.
+func planRecordType(recordType string) string {
+	if recordType == endpoint.RecordTypeAAAA {
+		return endpoint.RecordTypeAAAA
+	}
+	return ""
 }
 
 // planTableRow
@@ -95,24 +116,26 @@ func (t planTableRow) String() string {
 
 func (t planTable) addCurrent(e *endpoint.Endpoint) {
 	dnsName := normalizeDNSName(e.DNSName)
+	key := newPlanTableKey(e)
 	if _, ok := t.rows[dnsName]; !ok {
-		t.rows[dnsName] = make(map[string]*planTableRow)
+		t.rows[dnsName] = make(map[planTableKey]*planTableRow)
 	}
-	if _, ok := t.rows[dnsName][e.SetIdentifier]; !ok {
-		t.rows[dnsName][e.SetIdentifier] = &planTableRow{}
+	if _, ok := t.rows[dnsName][key]; !ok {
+		t.rows[dnsName][key] = &planTableRow{}
 	}
-	t.rows[dnsName][e.SetIdentifier].current = e
+	t.rows[dnsName][key].current = e
 }
 
 func (t planTable) addCandidate(e *endpoint.Endpoint) {
 	dnsName := normalizeDNSName(e.DNSName)
+	key := newPlanTableKey(e)
 	if _, ok := t.rows[dnsName]; !ok {
-		t.rows[dnsName] = make(map[string]*planTableRow)
+		t.rows[dnsName] = make(map[planTableKey]*planTableRow)
 	}
-	if _, ok := t.rows[dnsName][e.SetIdentifier]; !ok {
-		t.rows[dnsName][e.SetIdentifier] = &planTableRow{}
+	if _, ok := t.rows[dnsName][key]; !ok {
+		t.rows[dnsName][key] = &planTableRow{}
 	}
-	t.rows[dnsName][e.SetIdentifier].candidates = append(t.rows[dnsName][e.SetIdentifier].candidates, e)
+	t.rows[dnsName][key].candidates = append(t.rows[dnsName][key].candidates, e)
 }
 
 // Calculate computes the actions needed to move current state towards desired
@@ -160,7 +183,7 @@ func (p *Plan) Calculate() *Plan {
 		Current:        p.Current,
 		Desired:        p.Desired,
 		Changes:        changes,
-		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		ManagedRecords: p.ManagedRecords,
 	}
 
 	return plan
@@ -619,6 +619,56 @@ func (suite *PlanTestSuite) TestSetIdentifierUpdateCreatesAndDeletes() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestDualStackFirstRound() {
+	dualStackA := &endpoint.Endpoint{DNSName: "dual.bar", Targets: endpoint.Targets{"127.0.0.1"}, RecordType: endpoint.RecordTypeA}
+	dualStackAAAA := &endpoint.Endpoint{DNSName: "dual.bar", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA}
+
+	current := []*endpoint.Endpoint{}
+	desired := []*endpoint.Endpoint{dualStackA, dualStackAAAA}
+	expectedCreate := []*endpoint.Endpoint{dualStackA, dualStackAAAA}
+	expectedUpdateOld := []*endpoint.Endpoint{}
+	expectedUpdateNew := []*endpoint.Endpoint{}
+	expectedDelete := []*endpoint.Endpoint{}
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        current,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, expectedCreate)
+	validateEntries(suite.T(), changes.UpdateNew, expectedUpdateNew)
+	validateEntries(suite.T(), changes.UpdateOld, expectedUpdateOld)
+	validateEntries(suite.T(), changes.Delete, expectedDelete)
+}
+
+func (suite *PlanTestSuite) TestDualStackSecondRound() {
+	dualStackA := &endpoint.Endpoint{DNSName: "dual.bar", Targets: endpoint.Targets{"127.0.0.1"}, RecordType: endpoint.RecordTypeA}
+	dualStackAAAA := &endpoint.Endpoint{DNSName: "dual.bar", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA}
+
+	current := []*endpoint.Endpoint{dualStackA, dualStackAAAA}
+	desired := []*endpoint.Endpoint{dualStackA, dualStackAAAA}
+	expectedCreate := []*endpoint.Endpoint{}
+	expectedUpdateOld := []*endpoint.Endpoint{}
+	expectedUpdateNew := []*endpoint.Endpoint{}
+	expectedDelete := []*endpoint.Endpoint{}
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        current,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, expectedCreate)
+	validateEntries(suite.T(), changes.UpdateNew, expectedUpdateNew)
+	validateEntries(suite.T(), changes.UpdateOld, expectedUpdateOld)
+	validateEntries(suite.T(), changes.Delete, expectedDelete)
+}
+
 func (suite *PlanTestSuite) TestDomainFiltersInitial() {
 
 	current := []*endpoint.Endpoint{suite.domainFilterExcluded}
@@ -36,6 +36,9 @@ type TXTRegistry struct {
 	ownerID  string //refers to the owner id of the current instance
 	mapper   nameMapper
 
+	// owner ids, besides ownerID, that resources may request to manage their records with
+	allowedOwnerIDs map[string]bool
+
 	// cache the records in memory and update on an interval instead.
 	recordsCache            []*endpoint.Endpoint
 	recordsCacheRefreshTime time.Time
@@ -48,7 +51,7 @@ type TXTRegistry struct {
 }
 
 // NewTXTRegistry returns new TXTRegistry object
-func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration, txtWildcardReplacement string) (*TXTRegistry, error) {
+func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration, txtWildcardReplacement string, allowedOwnerIDs []string) (*TXTRegistry, error) {
 	if ownerID == "" {
 		return nil, errors.New("owner id cannot be empty")
 	}
@@ -59,10 +62,16 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 
 	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
 
+	allowed := make(map[string]bool, len(allowedOwnerIDs))
+	for _, id := range allowedOwnerIDs {
+		allowed[id] = true
+	}
+
 	return &TXTRegistry{
 		provider:            provider,
 		ownerID:             ownerID,
 		mapper:              mapper,
+		allowedOwnerIDs:     allowed,
 		cacheInterval:       cacheInterval,
 		wildcardReplacement: txtWildcardReplacement,
 	}, nil
@@ -105,7 +114,8 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 		if err != nil {
 			return nil, err
 		}
-		key := fmt.Sprintf("%s::%s", im.mapper.toEndpointName(record.DNSName), record.SetIdentifier)
+		endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
+		key := fmt.Sprintf("%s::%s::%s", endpointName, record.SetIdentifier, recordType)
 		labelMap[key] = labels
 	}
 
@@ -119,7 +129,7 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			dnsNameSplit[0] = im.wildcardReplacement
 		}
 		dnsName := strings.Join(dnsNameSplit, ".")
-		key := fmt.Sprintf("%s::%s", dnsName, ep.SetIdentifier)
+		key := fmt.Sprintf("%s::%s::%s", dnsName, ep.SetIdentifier, txtRecordType(ep.RecordType))
 		if labels, ok := labelMap[key]; ok {
 			for k, v := range labels {
 				ep.Labels[k] = v
@@ -140,17 +150,16 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
-		UpdateNew: filterOwnedRecords(im.ownerID, changes.UpdateNew),
-		UpdateOld: filterOwnedRecords(im.ownerID, changes.UpdateOld),
-		Delete:    filterOwnedRecords(im.ownerID, changes.Delete),
+		Create: changes.Create,
+		Delete: im.filterOwnedRecords(changes.Delete),
 	}
+	filteredChanges.UpdateNew, filteredChanges.UpdateOld = im.filterOwnedUpdates(changes.UpdateNew, changes.UpdateOld)
 	for _, r := range filteredChanges.Create {
 		if r.Labels == nil {
 			r.Labels = make(map[string]string)
 		}
-		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		r.Labels[endpoint.OwnerLabelKey] = im.recordOwnerID(r)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 		filteredChanges.Create = append(filteredChanges.Create, txt)
 
@@ -160,7 +169,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	}
 
 	for _, r := range filteredChanges.Delete {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 
 		// when we delete TXT records for which value has changed (due to new label) this would still work because
@@ -174,7 +183,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateOld {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 		// when we updateOld TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
@@ -187,7 +196,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateNew {
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName, r.RecordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true)).WithSetIdentifier(r.SetIdentifier)
 		txt.ProviderSpecific = r.ProviderSpecific
 		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, txt)
 		// add new version of record to cache
@@ -217,14 +226,75 @@ func (im *TXTRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) []*endpoi
   TXT registry specific private methods
 */
 
+// recordOwnerID returns the owner id the registry manages the record with, which is the owner id
+// requested by the resource of the record, if it is allowed, or else the owner id of the registry.
+func (im *TXTRegistry) recordOwnerID(ep *endpoint.Endpoint) string {
+	if ownerID := ep.Labels[endpoint.OwnerIDLabelKey]; im.allowedOwnerIDs[ownerID] {
+		return ownerID
+	}
+	return im.ownerID
+}
+
+// isOwned returns whether the record is owned by the registry, either through its owner id
+// or through the allowed owner id requested by the resource of the record.
+func (im *TXTRegistry) isOwned(ep *endpoint.Endpoint) bool {
+	owner, ok := ep.Labels[endpoint.OwnerLabelKey]
+	return ok && (owner == im.ownerID || owner == im.recordOwnerID(ep))
+}
+
+// filterOwnedRecords returns the records owned by the registry.
+func (im *TXTRegistry) filterOwnedRecords(eps []*endpoint.Endpoint) []*endpoint.Endpoint {
+	filtered := []*endpoint.Endpoint{}
+	for _, ep := range eps {
+		if !im.isOwned(ep) {
+			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, ep.Labels[endpoint.OwnerLabelKey], im.recordOwnerID(ep))
+			continue
+		}
+		filtered = append(filtered, ep)
+	}
+	return filtered
+}
+
+// filterOwnedUpdates returns the new and old records of the updates owned by the registry. The ownership
+// of an update is decided by its new record, which carries the owner id requested by its resource,
+// so that the new and old records are kept in pairs.
+func (im *TXTRegistry) filterOwnedUpdates(updateNew, updateOld []*endpoint.Endpoint) ([]*endpoint.Endpoint, []*endpoint.Endpoint) {
+	if len(updateNew) != len(updateOld) {
+		return im.filterOwnedRecords(updateNew), im.filterOwnedRecords(updateOld)
+	}
+	filteredNew, filteredOld := []*endpoint.Endpoint{}, []*endpoint.Endpoint{}
+	for i, ep := range updateNew {
+		if !im.isOwned(ep) {
+			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, ep.Labels[endpoint.OwnerLabelKey], im.recordOwnerID(ep))
+			continue
+		}
+		filteredNew = append(filteredNew, ep)
+		filteredOld = append(filteredOld, updateOld[i])
+	}
+	return filteredNew, filteredOld
+}
+
+// txtRecordTypePrefix is prepended to the first label of the TXT records of AAAA records, so that
+// they are kept apart from the TXT record of the A or CNAME record of the same name.
+const txtRecordTypePrefix = "aaaa-"
+
+// txtRecordType returns the record type the TXT record of a record of the given type is kept for,
+// which is AAAA for AAAA records and empty for all other records.
+func txtRecordType(recordType string) string {
+	if recordType == endpoint.RecordTypeAAAA {
+		return endpoint.RecordTypeAAAA
+	}
+	return ""
+}
+
 /**
   nameMapper defines interface which maps the dns name defined for the source
   to the dns name which TXT record will be created with
 */
 
 type nameMapper interface {
-	toEndpointName(string) string
-	toTXTName(string) string
+	toEndpointName(string) (string, string)
+	toTXTName(string, string) string
 }
 
 type affixNameMapper struct {
@@ -239,28 +309,42 @@ func newaffixNameMapper(prefix string, suffix string, wildcardReplacement string
 	return affixNameMapper{prefix: strings.ToLower(prefix), suffix: strings.ToLower(suffix), wildcardReplacement: strings.ToLower(wildcardReplacement)}
 }
 
-func (pr affixNameMapper) toEndpointName(txtDNSName string) string {
+// toEndpointName returns the dns name of the records the TXT record is kept for, along with their
+// record type as returned by txtRecordType.
+func (pr affixNameMapper) toEndpointName(txtDNSName string) (string, string) {
 	lowerDNSName := strings.ToLower(txtDNSName)
 	if strings.HasPrefix(lowerDNSName, pr.prefix) && len(pr.suffix) == 0 {
-		return strings.TrimPrefix(lowerDNSName, pr.prefix)
+		return splitTXTRecordType(strings.TrimPrefix(lowerDNSName, pr.prefix))
 	}
 
 	if len(pr.suffix) > 0 {
 		DNSName := strings.SplitN(lowerDNSName, ".", 2)
 		if strings.HasSuffix(DNSName[0], pr.suffix) {
-			return strings.TrimSuffix(DNSName[0], pr.suffix) + "." + DNSName[1]
+			return splitTXTRecordType(strings.TrimSuffix(DNSName[0], pr.suffix) + "." + DNSName[1])
 		}
 	}
-	return ""
+	return "", ""
 }
 
-func (pr affixNameMapper) toTXTName(endpointDNSName string) string {
+// splitTXTRecordType splits the record type prefix off the first label of the dns name.
+func splitTXTRecordType(dnsName string) (string, string) {
+	if strings.HasPrefix(dnsName, txtRecordTypePrefix) {
+		return strings.TrimPrefix(dnsName, txtRecordTypePrefix), endpoint.RecordTypeAAAA
+	}
+	return dnsName, ""
+}
+
+// toTXTName returns the dns name of the TXT record kept for the records of the given name and type.
+func (pr affixNameMapper) toTXTName(endpointDNSName, recordType string) string {
 	DNSName := strings.SplitN(endpointDNSName, ".", 2)
 
 	// If specified, replace a leading asterisk in the generated txt record name with some other string
 	if pr.wildcardReplacement != "" && DNSName[0] == "*" {
 		DNSName[0] = pr.wildcardReplacement
 	}
+	if txtRecordType(recordType) == endpoint.RecordTypeAAAA {
+		DNSName[0] = txtRecordTypePrefix + DNSName[0]
+	}
 
 	if len(DNSName) < 2 {
 		return pr.prefix + DNSName[0] + pr.suffix
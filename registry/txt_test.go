@@ -44,20 +44,20 @@ func TestTXTRegistry(t *testing.T) {
 
 func testTXTRegistryNew(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
-	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour, "")
+	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour, "", nil)
 	require.Error(t, err)
 
-	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour, "")
+	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour, "", nil)
 	require.Error(t, err)
 
-	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour, "")
+	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour, "", nil)
 	require.NoError(t, err)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour, "")
+	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour, "", nil)
 	require.NoError(t, err)
 
-	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour, "")
+	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour, "", nil)
 	require.Error(t, err)
 
 	_, ok := r.mapper.(affixNameMapper)
@@ -65,7 +65,7 @@ func testTXTRegistryNew(t *testing.T) {
 	assert.Equal(t, "owner", r.ownerID)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "")
+	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil)
 	require.NoError(t, err)
 
 	_, ok = r.mapper.(affixNameMapper)
@@ -181,13 +181,13 @@ func testTXTRegistryRecordsPrefixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc")
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc", nil)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour, "")
+	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour, "", nil)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpointLabels(records, expectedRecords))
@@ -286,13 +286,13 @@ func testTXTRegistryRecordsSuffixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "")
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "", nil)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour, "")
+	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour, "", nil)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpointLabels(records, expectedRecords))
@@ -367,7 +367,7 @@ func testTXTRegistryRecordsNoPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "")
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -377,6 +377,9 @@ func testTXTRegistryApplyChanges(t *testing.T) {
 	t.Run("With Prefix", testTXTRegistryApplyChangesWithPrefix)
 	t.Run("With Suffix", testTXTRegistryApplyChangesWithSuffix)
 	t.Run("No prefix", testTXTRegistryApplyChangesNoPrefix)
+	t.Run("Owner ID label", testTXTRegistryApplyChangesOwnerIDLabel)
+	t.Run("Foreign owner ID label", testTXTRegistryApplyChangesForeignOwnerIDLabel)
+	t.Run("Dual stack", testTXTRegistryApplyChangesDualStack)
 }
 
 func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
@@ -404,7 +407,7 @@ func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
 			newEndpointWithOwner("txt.multiple.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "")
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", nil)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -498,7 +501,7 @@ func testTXTRegistryApplyChangesWithSuffix(t *testing.T) {
 			newEndpointWithOwner("multiple-txt.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "wildcard")
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "wildcard", nil)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -591,7 +594,7 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 			newEndpointWithOwner("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "")
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -642,6 +645,167 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func testTXTRegistryApplyChangesOwnerIDLabel(t *testing.T) {
+	teamA := endpoint.Labels{endpoint.OwnerIDLabelKey: "team-a"}
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	ctx := context.Background()
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("baz.test-zone.example.org", "baz.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("baz.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-b\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{"team-a"})
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "", teamA),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+			newEndpointWithOwner("baz.test-zone.example.org", "baz.loadbalancer.com", endpoint.RecordTypeCNAME, "team-b"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("bar.test-zone.example.org", "new-bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a"),
+		},
+	}
+	expected := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+			newEndpointWithOwner("new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("bar.test-zone.example.org", "new-bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a"),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+	}
+	p.OnApplyChanges = func(ctx context.Context, got *plan.Changes) {
+		mExpected := map[string][]*endpoint.Endpoint{
+			"Create":    expected.Create,
+			"UpdateNew": expected.UpdateNew,
+			"UpdateOld": expected.UpdateOld,
+			"Delete":    expected.Delete,
+		}
+		mGot := map[string][]*endpoint.Endpoint{
+			"Create":    got.Create,
+			"UpdateNew": got.UpdateNew,
+			"UpdateOld": got.UpdateOld,
+			"Delete":    got.Delete,
+		}
+		assert.True(t, testutils.SamePlanChanges(mGot, mExpected))
+	}
+	err := r.ApplyChanges(ctx, changes)
+	require.NoError(t, err)
+}
+
+func testTXTRegistryApplyChangesForeignOwnerIDLabel(t *testing.T) {
+	teamA := endpoint.Labels{endpoint.OwnerIDLabelKey: "team-a"}
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	ctx := context.Background()
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("foo.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("bar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+	// The owner id requested by the resources is not allowed, so the records of team-a are left alone.
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", nil)
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "", teamA),
+		},
+		Delete: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("bar.test-zone.example.org", "new-bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a", teamA),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("bar.test-zone.example.org", "bar.loadbalancer.com", endpoint.RecordTypeCNAME, "team-a"),
+		},
+	}
+	expected := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerAndLabels("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner", teamA),
+			newEndpointWithOwner("new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner,external-dns/owner-id=team-a\"", endpoint.RecordTypeTXT, ""),
+		},
+	}
+	p.OnApplyChanges = func(ctx context.Context, got *plan.Changes) {
+		mExpected := map[string][]*endpoint.Endpoint{
+			"Create":    expected.Create,
+			"UpdateNew": expected.UpdateNew,
+			"UpdateOld": expected.UpdateOld,
+			"Delete":    expected.Delete,
+		}
+		mGot := map[string][]*endpoint.Endpoint{
+			"Create":    got.Create,
+			"UpdateNew": got.UpdateNew,
+			"UpdateOld": got.UpdateOld,
+			"Delete":    got.Delete,
+		}
+		assert.True(t, testutils.SamePlanChanges(mGot, mExpected))
+	}
+	err := r.ApplyChanges(ctx, changes)
+	require.NoError(t, err)
+}
+
+func testTXTRegistryApplyChangesDualStack(t *testing.T) {
+	for _, prefix := range []string{"", "txt."} {
+		p := inmemory.NewInMemoryProvider()
+		p.CreateZone(testZone)
+		ctx := context.Background()
+		r, _ := NewTXTRegistry(p, prefix, "", "owner", time.Hour, "", nil)
+
+		expectedTXTs := &plan.Changes{
+			Create: []*endpoint.Endpoint{
+				newEndpointWithOwner("dual.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+				newEndpointWithOwner("dual.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, "owner"),
+				newEndpointWithOwner(prefix+"dual.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+				newEndpointWithOwner(prefix+"aaaa-dual.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			},
+		}
+		p.OnApplyChanges = func(ctx context.Context, got *plan.Changes) {
+			assert.True(t, testutils.SameEndpoints(got.Create, expectedTXTs.Create))
+		}
+		err := r.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{
+				newEndpointWithOwner("dual.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+				newEndpointWithOwner("dual.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, ""),
+			},
+		})
+		require.NoError(t, err)
+
+		// Both records are owned through their own TXT record.
+		r, _ = NewTXTRegistry(p, prefix, "", "owner", time.Hour, "", nil)
+		records, err := r.Records(ctx)
+		require.NoError(t, err)
+		assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+			newEndpointWithOwner("dual.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("dual.test-zone.example.org", "2001:db8::1", endpoint.RecordTypeAAAA, "owner"),
+		}))
+	}
+}
+
 func TestCacheMethods(t *testing.T) {
 	cache := []*endpoint.Endpoint{
 		newEndpointWithOwner("thing.com", "1.2.3.4", "A", "owner"),
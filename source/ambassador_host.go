@@ -26,12 +26,11 @@ import (
 	ambassador "github.com/datawire/ambassador/pkg/api/getambassador.io/v2"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
@@ -43,7 +42,7 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
-// ambHostAnnotation is the annotation in the Host that maps to a Service
+// ambHostAnnotation is the annotation in the Host that maps to a Service, or to a comma-separated list of Services
 const ambHostAnnotation = "external-dns.ambassador-service"
 
 // groupName is the group name for the Ambassador API
@@ -57,20 +56,33 @@ var ambHostGVR = schemeGroupVersion.WithResource("hosts")
 // The IngressRoute implementation uses the spec.virtualHost.fqdn value for the hostname.
 // Use targetAnnotationKey to explicitly set Endpoint.
 type ambassadorHostSource struct {
-	dynamicKubeClient      dynamic.Interface
-	kubeClient             kubernetes.Interface
-	namespace              string
-	ambassadorHostInformer informers.GenericInformer
-	unstructuredConverter  *unstructuredConverter
+	dynamicKubeClient            dynamic.Interface
+	kubeClient                   kubernetes.Interface
+	namespace                    string
+	mergeServiceProviderSpecific bool
+	targetLookupRetries          int
+	ambassadorHostInformer       informers.GenericInformer
+	unstructuredConverter        *unstructuredConverter
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewAmbassadorHostSource creates a new ambassadorHostSource with the given config.
 func NewAmbassadorHostSource(
 	dynamicKubeClient dynamic.Interface,
 	kubeClient kubernetes.Interface,
-	namespace string) (Source, error) {
+	namespace string,
+	mergeServiceProviderSpecific bool,
+	targetLookupRetries int,
+	cacheSyncTimeout time.Duration) (Source, error) {
 	var err error
 
+	uc, err := newUnstructuredConverter()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to setup Unstructured Converter")
+	}
+
 	// Use shared informer to listen for add/update/delete of Host in the specified namespace.
 	// Set resync period to 0, to prevent processing when nothing has changed.
 	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
@@ -84,34 +96,35 @@ func NewAmbassadorHostSource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return ambassadorHostInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("ambassador-host", "Host", cacheSyncTimeout, ambassadorHostInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to sync cache")
-	}
-
-	uc, err := newUnstructuredConverter()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to setup Unstructured Converter")
+		stop.Close()
+		return nil, err
 	}
 
 	return &ambassadorHostSource{
-		dynamicKubeClient:      dynamicKubeClient,
-		kubeClient:             kubeClient,
-		namespace:              namespace,
-		ambassadorHostInformer: ambassadorHostInformer,
-		unstructuredConverter:  uc,
+		stopper:                      stop,
+		informerHealth:               newInformerHealth("ambassador-host", ambassadorHostInformer.Informer().HasSynced),
+		dynamicKubeClient:            dynamicKubeClient,
+		kubeClient:                   kubeClient,
+		namespace:                    namespace,
+		mergeServiceProviderSpecific: mergeServiceProviderSpecific,
+		targetLookupRetries:          targetLookupRetries,
+		ambassadorHostInformer:       ambassadorHostInformer,
+		unstructuredConverter:        uc,
 	}, nil
 }
 
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all Hosts in the source's namespace(s).
 func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("ambassador-host")
+
 	hosts, err := sc.ambassadorHostInformer.Lister().ByNamespace(sc.namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
@@ -119,6 +132,12 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 
 	endpoints := []*endpoint.Endpoint{}
 	for _, hostObj := range hosts {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
 		unstructuredHost, ok := hostObj.(*unstructured.Unstructured)
 		if !ok {
 			return nil, errors.New("could not convert")
@@ -129,31 +148,55 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 		if err != nil {
 			return nil, err
 		}
+		host.Annotations = ensureAnnotations(host.Annotations)
+		host.Annotations, _ = sc.translateAnnotations(host.Annotations)
 
 		fullname := fmt.Sprintf("%s/%s", host.Namespace, host.Name)
 
+		// Check controller annotation to see if we are responsible.
+		controller, ok := host.Annotations[controllerAnnotationKey]
+		if ok && controller != controllerAnnotationValue {
+			log.Debugf("Skipping Host %s because controller value does not match, found: %s, required: %s",
+				fullname, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+
 		// look for the "exernal-dns.ambassador-service" annotation. If it is not there then just ignore this `Host`
 		service, found := host.Annotations[ambHostAnnotation]
 		if !found {
 			log.Debugf("Host %s ignored: no annotation %q found", fullname, ambHostAnnotation)
+			metrics.skip(skipReasonNoAnnotation)
 			continue
 		}
 
-		targets, err := sc.targetsFromAmbassadorLoadBalancer(ctx, service)
+		targets, serviceAnnotations, err := sc.targetsFromAmbassadorLoadBalancer(ctx, service)
 		if err != nil {
 			return nil, err
 		}
 
-		hostEndpoints, err := sc.endpointsFromHost(ctx, host, targets)
+		// Only the provider-specific annotations of the service are merged, those of the Host win.
+		var providerSpecificAnnotations map[string]string
+		if sc.mergeServiceProviderSpecific {
+			providerSpecificAnnotations = mergeAnnotations(filterProviderSpecificAnnotations(serviceAnnotations), host.Annotations)
+		}
+
+		hostEndpoints, err := sc.endpointsFromHost(ctx, host, additionalHostnames(unstructuredHost), targets, providerSpecificAnnotations)
 		if err != nil {
 			return nil, err
 		}
 		if len(hostEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from Host %s", fullname)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from Host: %s: %v", fullname, hostEndpoints)
+		setDualstackLabel(host.Annotations, "Host "+fullname, hostEndpoints)
+		setOwnerIDLabel(host.Annotations, hostEndpoints)
+		for _, ep := range hostEndpoints {
+			ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("host/%s/%s", host.Namespace, host.Name)
+		}
 		endpoints = append(endpoints, hostEndpoints...)
 	}
 
@@ -161,42 +204,101 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
-// endpointsFromHost extracts the endpoints from a Host object
-func (sc *ambassadorHostSource) endpointsFromHost(ctx context.Context, host *ambassador.Host, targets endpoint.Targets) ([]*endpoint.Endpoint, error) {
+// endpointsFromHost extracts the endpoints from a Host object and the given additional hostnames.
+// Each hostname gets its endpoints only once.
+// The TTL is read from the Host itself, the provider-specific properties and the set identifier from the
+// given annotations, so the endpoints have none without them.
+func (sc *ambassadorHostSource) endpointsFromHost(ctx context.Context, host *ambassador.Host, hostnames []string, targets endpoint.Targets, providerSpecificAnnotations map[string]string) ([]*endpoint.Endpoint, error) {
 	var endpoints []*endpoint.Endpoint
 
-	providerSpecific := endpoint.ProviderSpecific{}
-	setIdentifier := ""
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(providerSpecificAnnotations)
+	recordType := getRecordTypeFromAnnotations(host.Annotations)
 
-	annotations := host.Annotations
-	ttl, err := getTTLFromAnnotations(annotations)
+	ttl, err := getTTLFromAnnotations(host.Annotations)
 	if err != nil {
-		return nil, err
+		log.Warn(err)
 	}
 
 	if host.Spec != nil {
-		hostname := host.Spec.Hostname
-		if hostname != "" {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		hostnames = append([]string{host.Spec.Hostname}, hostnames...)
+	}
+
+	seen := make(map[string]bool, len(hostnames))
+	for _, hostname := range hostnames {
+		if hostname == "" || seen[hostname] {
+			continue
 		}
+		seen[hostname] = true
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	return endpoints, nil
 }
 
-func (sc *ambassadorHostSource) targetsFromAmbassadorLoadBalancer(ctx context.Context, service string) (targets endpoint.Targets, err error) {
-	lbNamespace, lbName, err := parseAmbLoadBalancerService(service)
+// additionalHostnames returns the names listed in spec.hostnames of the given Host.
+// The field is not part of the typed Host, so it is read from the unstructured object.
+func additionalHostnames(host *unstructured.Unstructured) []string {
+	hostnames, _, err := unstructured.NestedStringSlice(host.Object, "spec", "hostnames")
 	if err != nil {
-		return nil, err
+		log.Debugf("Host %s/%s: ignoring invalid spec.hostnames: %v", host.GetNamespace(), host.GetName(), err)
+		return nil
 	}
+	return hostnames
+}
 
-	svc, err := sc.kubeClient.CoreV1().Services(lbNamespace).Get(ctx, lbName, metav1.GetOptions{})
+// targetsFromAmbassadorLoadBalancer returns the union of the load balancer targets of the given
+// comma-separated services along with their annotations. The annotations of the services listed
+// first take precedence.
+func (sc *ambassadorHostSource) targetsFromAmbassadorLoadBalancer(ctx context.Context, services string) (endpoint.Targets, map[string]string, error) {
+	// Parse all of the services first so that a malformed entry fails the Host before any lookup.
+	var lbServices []types.NamespacedName
+	for _, service := range strings.Split(services, ",") {
+		service = strings.TrimSpace(service)
+		if service == "" {
+			return nil, nil, errors.Errorf("invalid external-dns services: empty entry in %q", services)
+		}
+		lbNamespace, lbName, err := parseAmbLoadBalancerService(service)
+		if err != nil {
+			return nil, nil, err
+		}
+		lbServices = append(lbServices, types.NamespacedName{Namespace: lbNamespace, Name: lbName})
+	}
+
+	var targets endpoint.Targets
+	var annotations map[string]string
+	seen := map[string]bool{}
+	for _, lbService := range lbServices {
+		svcTargets, svcAnnotations, err := sc.targetsFromAmbassadorService(ctx, lbService.Namespace, lbService.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, target := range svcTargets {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+		if annotations == nil {
+			annotations = svcAnnotations
+		} else {
+			annotations = mergeAnnotations(svcAnnotations, annotations)
+		}
+	}
+
+	return targets, annotations, nil
+}
+
+// targetsFromAmbassadorService returns the load balancer targets and the annotations of the given service.
+func (sc *ambassadorHostSource) targetsFromAmbassadorService(ctx context.Context, lbNamespace, lbName string) (targets endpoint.Targets, annotations map[string]string, err error) {
+	svc, err := getServiceWithRetries(ctx, sc.kubeClient, lbNamespace, lbName, sc.targetLookupRetries)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	annotations, _ = sc.translateAnnotations(svc.Annotations)
 
 	for _, lb := range svc.Status.LoadBalancer.Ingress {
 		if lb.IP != "" {
@@ -207,9 +309,41 @@ func (sc *ambassadorHostSource) targetsFromAmbassadorLoadBalancer(ctx context.Co
 		}
 	}
 
+	// Services without a load balancer, e.g. on bare metal, may be reachable through their external IPs.
+	if len(targets) == 0 {
+		targets = append(targets, svc.Spec.ExternalIPs...)
+	}
+
 	return
 }
 
+// filterProviderSpecificAnnotations returns the annotations which are read as provider-specific properties
+// or as the set identifier.
+func filterProviderSpecificAnnotations(annotations map[string]string) map[string]string {
+	filtered := map[string]string{}
+	for k, v := range annotations {
+		switch {
+		case k == CloudflareProxiedKey, k == aliasAnnotationKey, k == SetIdentifierKey,
+			strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/aws-"),
+			strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/scw-"):
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// mergeAnnotations returns the union of both annotation maps, preferring the values of overrides.
+func mergeAnnotations(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
 // parseAmbLoadBalancerService returns a name/namespace tuple from the annotation in
 // an Ambassador Host CRD
 //
@@ -17,10 +17,21 @@ limitations under the License.
 package source
 
 import (
+	"context"
 	"testing"
 
+	ambassador "github.com/datawire/ambassador/pkg/api/getambassador.io/v2"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 type AmbassadorSuite struct {
@@ -76,3 +87,435 @@ func TestParseAmbLoadBalancerService(t *testing.T) {
 		}
 	}
 }
+
+func TestAmbassadorHostSourceEndpoints(t *testing.T) {
+	for _, ti := range []struct {
+		title                        string
+		mergeServiceProviderSpecific bool
+		hostAnnotations              map[string]string
+		serviceAnnotations           map[string]string
+		expectedProviderSpecific     endpoint.ProviderSpecific
+		expectedSetIdentifier        string
+	}{
+		{
+			title: "provider-specific annotations are ignored by default",
+			hostAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "FR",
+				SetIdentifierKey: "host",
+			},
+			serviceAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "DE",
+				SetIdentifierKey: "service",
+			},
+			expectedProviderSpecific: endpoint.ProviderSpecific{},
+		},
+		{
+			title:                        "service provider-specific annotations are merged",
+			mergeServiceProviderSpecific: true,
+			serviceAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "DE",
+				SetIdentifierKey: "service",
+			},
+			expectedProviderSpecific: endpoint.ProviderSpecific{
+				{Name: "aws/geolocation-country-code", Value: "DE"},
+			},
+			expectedSetIdentifier: "service",
+		},
+		{
+			title:                        "host provider-specific annotations win over the service",
+			mergeServiceProviderSpecific: true,
+			hostAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "FR",
+				SetIdentifierKey: "host",
+			},
+			serviceAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "DE",
+				SetIdentifierKey: "service",
+			},
+			expectedProviderSpecific: endpoint.ProviderSpecific{
+				{Name: "aws/geolocation-country-code", Value: "FR"},
+			},
+			expectedSetIdentifier: "host",
+		},
+		{
+			title:                        "other service annotations are not merged",
+			mergeServiceProviderSpecific: true,
+			serviceAnnotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "DE",
+				SetIdentifierKey:          "service",
+				ttlAnnotationKey:          "60",
+				recordTypeAnnotationKey:   endpoint.RecordTypeCNAME,
+				ALBDualstackAnnotationKey: ALBDualstackAnnotationValue,
+			},
+			expectedProviderSpecific: endpoint.ProviderSpecific{
+				{Name: "aws/geolocation-country-code", Value: "DE"},
+			},
+			expectedSetIdentifier: "service",
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			fakeKubernetesClient := fakeKube.NewSimpleClientset()
+			svc := (fakeLoadBalancerService{
+				ips:       []string{"1.2.3.4"},
+				namespace: "ambassador",
+				name:      "ambassador",
+			}).Service()
+			svc.Annotations = ti.serviceAnnotations
+			_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			annotations := map[string]string{ambHostAnnotation: "ambassador/ambassador"}
+			for k, v := range ti.hostAnnotations {
+				annotations[k] = v
+			}
+			fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+			src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", ti.mergeServiceProviderSpecific, 0, 0)
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.example.org",
+					Targets:    endpoint.Targets{"1.2.3.4"},
+					RecordType: endpoint.RecordTypeA,
+				},
+			})
+			assert.Equal(t, ti.expectedProviderSpecific, endpoints[0].ProviderSpecific)
+			assert.Equal(t, ti.expectedSetIdentifier, endpoints[0].SetIdentifier)
+			assert.NotContains(t, endpoints[0].Labels, endpoint.DualstackLabelKey)
+		})
+	}
+}
+
+func newAmbassadorDynamicClient(t *testing.T, hosts ...*ambassador.Host) *fakeDynamic.FakeDynamicClient {
+	s := runtime.NewScheme()
+	require.NoError(t, ambassador.AddToScheme(s))
+	client := fakeDynamic.NewSimpleDynamicClient(s)
+
+	for _, host := range hosts {
+		unstructuredHost := &unstructured.Unstructured{}
+		require.NoError(t, s.Convert(host, unstructuredHost, context.Background()))
+		_, err := client.Resource(ambHostGVR).Namespace(host.Namespace).Create(context.Background(), unstructuredHost, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	return client
+}
+
+func fakeAmbassadorHost(name, namespace, hostname string, annotations map[string]string) *ambassador.Host {
+	return &ambassador.Host{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: ambassador.GroupVersion.String(),
+			Kind:       "Host",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: &ambassador.HostSpec{
+			Hostname: hostname,
+		},
+	}
+}
+
+func TestAmbassadorHostSourceExternalIPs(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ambassador",
+			Name:      "ambassador",
+		},
+		Spec: v1.ServiceSpec{
+			Type:        v1.ServiceTypeClusterIP,
+			ExternalIPs: []string{"1.2.3.4", "1.2.3.5"},
+		},
+	}
+	_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	annotations := map[string]string{ambHostAnnotation: "ambassador/ambassador"}
+	fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4", "1.2.3.5"},
+			RecordType: endpoint.RecordTypeA,
+		},
+	})
+}
+
+func TestAmbassadorHostSourceDualstackLabel(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ambassador",
+			Name:      "ambassador",
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			},
+		},
+	}
+	_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	annotations := map[string]string{
+		ambHostAnnotation:         "ambassador/ambassador",
+		ALBDualstackAnnotationKey: ALBDualstackAnnotationValue,
+	}
+	fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "true", endpoints[0].Labels[endpoint.DualstackLabelKey])
+}
+
+func TestAmbassadorHostSourceEndpointsCancelled(t *testing.T) {
+	annotations := map[string]string{ambHostAnnotation: "ambassador/ambassador"}
+	fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKube.NewSimpleClientset(), "", false, 0, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = src.Endpoints(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestAmbassadorHostSourceAdditionalHostnames(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ambassador",
+			Name:      "ambassador",
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	s := runtime.NewScheme()
+	require.NoError(t, ambassador.AddToScheme(s))
+	fakeDynamicClient := fakeDynamic.NewSimpleDynamicClient(s)
+
+	annotations := map[string]string{ambHostAnnotation: "ambassador/ambassador"}
+	unstructuredHost := &unstructured.Unstructured{}
+	require.NoError(t, s.Convert(fakeAmbassadorHost("wildcard", "default", "*.example.org", annotations), unstructuredHost, context.Background()))
+	require.NoError(t, unstructured.SetNestedStringSlice(unstructuredHost.Object, []string{"foo.example.org", "bar.example.org", "*.example.org"}, "spec", "hostnames"))
+	_, err = fakeDynamicClient.Resource(ambHostGVR).Namespace("default").Create(context.Background(), unstructuredHost, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:    "*.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+		},
+		{
+			DNSName:    "foo.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+		},
+		{
+			DNSName:    "bar.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+		},
+	})
+}
+
+func TestAmbassadorHostSourceMultipleServices(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	for _, svc := range []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ambassador", Name: "ambassador-east"},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}, {IP: "1.2.3.6"}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ambassador", Name: "ambassador-west"},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.5"}, {IP: "1.2.3.6"}},
+				},
+			},
+		},
+	} {
+		_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	for _, ti := range []struct {
+		title       string
+		service     string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title:   "union of targets",
+			service: "ambassador/ambassador-east, ambassador-west.ambassador",
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.example.org",
+					Targets:    endpoint.Targets{"1.2.3.4", "1.2.3.5", "1.2.3.6"},
+					RecordType: endpoint.RecordTypeA,
+				},
+			},
+		},
+		{
+			title:       "malformed entry",
+			service:     "ambassador/ambassador-east,ambassador/west/extra",
+			expectError: true,
+		},
+		{
+			title:       "empty entry",
+			service:     "ambassador/ambassador-east,,ambassador/ambassador-west",
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			annotations := map[string]string{ambHostAnnotation: ti.service}
+			fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+			src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+func TestAmbassadorHostSourceInvalidTTL(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ambassador",
+			Name:      "ambassador",
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	annotations := map[string]string{
+		ambHostAnnotation: "ambassador/ambassador",
+		ttlAnnotationKey:  "-1m",
+	}
+	fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.example.org",
+			Targets:    endpoint.Targets{"1.2.3.4"},
+			RecordType: endpoint.RecordTypeA,
+		},
+	})
+}
+
+func TestAmbassadorHostSourceWithoutAnnotations(t *testing.T) {
+	fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", nil))
+
+	src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKube.NewSimpleClientset(), "", false, 0, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+}
+
+func TestAmbassadorHostSourceForeignController(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ambassador",
+			Name:      "ambassador",
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		title      string
+		controller string
+		expected   []*endpoint.Endpoint
+	}{
+		{
+			title:      "foreign controller",
+			controller: "some-other-tool",
+		},
+		{
+			title:      "our controller",
+			controller: controllerAnnotationValue,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.example.org",
+					Targets:    endpoint.Targets{"1.2.3.4"},
+					RecordType: endpoint.RecordTypeA,
+				},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			annotations := map[string]string{
+				ambHostAnnotation:       "ambassador/ambassador",
+				controllerAnnotationKey: tc.controller,
+			}
+			fakeDynamicClient := newAmbassadorDynamicClient(t, fakeAmbassadorHost("foo", "default", "foo.example.org", annotations))
+
+			src, err := NewAmbassadorHostSource(fakeDynamicClient, fakeKubernetesClient, "", false, 0, 0)
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
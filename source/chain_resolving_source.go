@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Resolver looks up DNS records. It is satisfied by *net.Resolver.
+type Resolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// chainResolvingSource is a Source that, in addition to the endpoints of the wrapped source,
+// emits A and AAAA endpoints for each hop of the CNAME chains those endpoints point at.
+type chainResolvingSource struct {
+	source   Source
+	resolver Resolver
+	maxDepth int
+}
+
+// NewChainResolvingSource creates a new chainResolvingSource wrapping the provided Source.
+// At most maxDepth hops of each CNAME chain are materialized.
+func NewChainResolvingSource(source Source, resolver Resolver, maxDepth int) Source {
+	return &chainResolvingSource{source: source, resolver: resolver, maxDepth: maxDepth}
+}
+
+// Endpoints returns the endpoints of the wrapped source followed by the address endpoints
+// of the hostnames in their CNAME chains. Chains that fail to resolve are skipped.
+func (cs *chainResolvingSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, err := cs.source.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cs.withChainEndpoints(ctx, endpoints), nil
+}
+
+// EndpointsWithWarnings collects endpoints and warnings from its wrapped source and returns the
+// endpoints followed by the address endpoints of the hostnames in their CNAME chains.
+func (cs *chainResolvingSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	endpoints, warnings, err := EndpointsWithWarnings(ctx, cs.source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cs.withChainEndpoints(ctx, endpoints), warnings, nil
+}
+
+// withChainEndpoints returns the endpoints followed by the address endpoints of the hostnames
+// in their CNAME chains.
+func (cs *chainResolvingSource) withChainEndpoints(ctx context.Context, endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	result := append([]*endpoint.Endpoint{}, endpoints...)
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeCNAME {
+			continue
+		}
+		for _, target := range ep.Targets {
+			chainEndpoints, err := cs.endpointsForChain(ctx, ep, target)
+			if err != nil {
+				log.Warnf("Failed to resolve CNAME chain of %s for endpoint %s: %v", target, ep.DNSName, err)
+				continue
+			}
+			result = append(result, chainEndpoints...)
+		}
+	}
+	return result
+}
+
+// endpointsForChain follows the CNAME chain starting at target and returns A and AAAA endpoints
+// with the addresses at the end of the chain for each hostname along the way.
+func (cs *chainResolvingSource) endpointsForChain(ctx context.Context, ep *endpoint.Endpoint, target string) ([]*endpoint.Endpoint, error) {
+	var hops []string
+	host := strings.TrimSuffix(target, ".")
+	for len(hops) < cs.maxDepth {
+		hops = append(hops, host)
+
+		cname, err := cs.resolver.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		cname = strings.TrimSuffix(cname, ".")
+		// the canonical name of a host without a CNAME record is the host itself
+		if cname == "" || cname == host {
+			break
+		}
+		host = cname
+	}
+
+	addrs, err := cs.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var ipv4, ipv6 endpoint.Targets
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ipv4 = append(ipv4, addr.IP.String())
+		} else {
+			ipv6 = append(ipv6, addr.IP.String())
+		}
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, hop := range hops {
+		if len(ipv4) > 0 {
+			endpoints = append(endpoints, chainEndpoint(ep, hop, endpoint.RecordTypeA, ipv4))
+		}
+		if len(ipv6) > 0 {
+			endpoints = append(endpoints, chainEndpoint(ep, hop, endpoint.RecordTypeAAAA, ipv6))
+		}
+	}
+	return endpoints, nil
+}
+
+func chainEndpoint(ep *endpoint.Endpoint, hostname, recordType string, targets endpoint.Targets) *endpoint.Endpoint {
+	labels := endpoint.NewLabels()
+	for k, v := range ep.Labels {
+		labels[k] = v
+	}
+	return &endpoint.Endpoint{
+		DNSName:    hostname,
+		Targets:    targets,
+		RecordType: recordType,
+		RecordTTL:  ep.RecordTTL,
+		Labels:     labels,
+	}
+}
+
+func (cs *chainResolvingSource) AddEventHandler(ctx context.Context, handler func()) {
+	cs.source.AddEventHandler(ctx, handler)
+}
+
+// Close closes the wrapped source if it can be closed.
+func (cs *chainResolvingSource) Close() error {
+	return closeSource(cs.source)
+}
+
+// Healthy reports the health of the wrapped source.
+func (cs *chainResolvingSource) Healthy() error {
+	return CheckHealth(cs.source)
+}
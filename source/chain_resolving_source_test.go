@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that chainResolvingSource is a Source
+var _ Source = &chainResolvingSource{}
+
+// fakeResolver resolves from static CNAME and address maps.
+type fakeResolver struct {
+	cnames map[string]string
+	addrs  map[string][]string
+}
+
+func (r *fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, ok := r.cnames[host]; ok {
+		return cname + ".", nil
+	}
+	if _, ok := r.addrs[host]; ok {
+		return host + ".", nil
+	}
+	return "", errors.New("no such host")
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	for r.cnames[host] != "" {
+		host = r.cnames[host]
+	}
+	ips, ok := r.addrs[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	var addrs []net.IPAddr
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestChainResolvingSource(t *testing.T) {
+	resolver := &fakeResolver{
+		cnames: map[string]string{
+			"lb.example.org":     "lb.eu.example.org",
+			"lb.eu.example.org":  "edge.example.net",
+			"broken.example.org": "missing.example.net",
+		},
+		addrs: map[string][]string{
+			"edge.example.net": {"1.2.3.4", "2001:db8::1"},
+		},
+	}
+
+	for _, tc := range []struct {
+		title     string
+		maxDepth  int
+		endpoints []*endpoint.Endpoint
+		expected  []*endpoint.Endpoint
+	}{
+		{
+			title:    "address records are returned unchanged",
+			maxDepth: 5,
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:    "two-hop chain materializes every hop",
+			maxDepth: 5,
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 300},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 300},
+				{DNSName: "lb.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+				{DNSName: "lb.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+				{DNSName: "lb.eu.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+				{DNSName: "lb.eu.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+				{DNSName: "edge.example.net", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+				{DNSName: "edge.example.net", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+			},
+		},
+		{
+			title:    "depth limits the materialized hops",
+			maxDepth: 1,
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+				{DNSName: "lb.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "lb.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+		},
+		{
+			title:    "failed resolution keeps the original CNAME",
+			maxDepth: 5,
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"broken.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"broken.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return(tc.endpoints, nil)
+
+			source := NewChainResolvingSource(mockSource, resolver, tc.maxDepth)
+
+			endpoints, err := source.Endpoints(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validateEndpoints(t, endpoints, tc.expected)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
+
+// TestChainResolvingSourceWarnings tests that the warnings of the wrapped source are forwarded.
+func TestChainResolvingSourceWarnings(t *testing.T) {
+	resolver := &fakeResolver{
+		addrs: map[string][]string{
+			"lb.example.org": {"1.2.3.4"},
+		},
+	}
+	inner := &warningSource{
+		Source:    NewEmptySource(),
+		endpoints: []*endpoint.Endpoint{{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME}},
+		warnings:  []Warning{{Resource: "HTTPProxy/default/bar", Reason: skipReasonNoEndpoints, Message: "no targets"}},
+	}
+
+	endpoints, warnings, err := EndpointsWithWarnings(context.Background(), NewChainResolvingSource(inner, resolver, 5))
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+		{DNSName: "lb.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	})
+	assert.Equal(t, inner.warnings, warnings)
+}
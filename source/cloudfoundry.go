@@ -59,5 +59,6 @@ func (rs *cloudfoundrySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoi
 		}
 	}
 
+	newSourceMetrics("cloudfoundry").publish(endpoints)
 	return endpoints, nil
 }
@@ -0,0 +1,44 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestClusterLabelSource(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/default/foo"}},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME},
+	}, nil)
+
+	endpoints, err := NewModifiedSource(mockSource, LabelCluster("eu-west")).Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+
+	assert.Equal(t, endpoint.Labels{endpoint.ResourceLabelKey: "service/default/foo", endpoint.ClusterLabelKey: "eu-west"}, endpoints[0].Labels)
+	assert.Equal(t, endpoint.Labels{endpoint.ClusterLabelKey: "eu-west"}, endpoints[1].Labels)
+	mockSource.AssertExpectations(t)
+}
@@ -63,6 +63,7 @@ func (cs *connectorSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 
 	log.Debugf("Received endpoints: %#v", endpoints)
 
+	newSourceMetrics("connector").publish(endpoints)
 	return endpoints, nil
 }
 
@@ -44,6 +44,7 @@ type crdSource struct {
 	codec            runtime.ParameterCodec
 	annotationFilter string
 	labelFilter      string
+	annotationPrefixer
 }
 
 func addKnownTypes(scheme *runtime.Scheme, groupVersion schema.GroupVersion) error {
@@ -135,6 +136,10 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		return nil, err
 	}
 
+	for i := range result.Items {
+		result.Items[i].Annotations, _ = cs.translateAnnotations(result.Items[i].Annotations)
+	}
+
 	result, err = cs.filterByAnnotations(result)
 
 	if err != nil {
@@ -170,6 +175,7 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		}
 
 		cs.setResourceLabel(&dnsEndpoint, crdEndpoints)
+		setOwnerIDLabel(dnsEndpoint.Annotations, crdEndpoints)
 		endpoints = append(endpoints, crdEndpoints...)
 
 		if dnsEndpoint.Status.ObservedGeneration == dnsEndpoint.Generation {
@@ -184,6 +190,7 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		}
 	}
 
+	newSourceMetrics("crd").publish(endpoints)
 	return endpoints, nil
 }
 
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// debounceSource is a Source that coalesces the events of its wrapped source.
+type debounceSource struct {
+	source   Source
+	interval time.Duration
+}
+
+// NewDebounceSource creates a new debounceSource wrapping the provided Source.
+// The event handlers added to it are called at most once per interval.
+func NewDebounceSource(source Source, interval time.Duration) Source {
+	return &debounceSource{source: source, interval: interval}
+}
+
+// Endpoints collects endpoints from its wrapped source.
+func (ds *debounceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	return ds.source.Endpoints(ctx)
+}
+
+// EndpointsWithWarnings collects endpoints and warnings from its wrapped source.
+func (ds *debounceSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	return EndpointsWithWarnings(ctx, ds.source)
+}
+
+func (ds *debounceSource) AddEventHandler(ctx context.Context, handler func()) {
+	ds.source.AddEventHandler(ctx, debounce(ctx, ds.interval, handler))
+}
+
+// Close closes the wrapped source if it can be closed.
+func (ds *debounceSource) Close() error {
+	return closeSource(ds.source)
+}
+
+// Healthy reports the health of the wrapped source.
+func (ds *debounceSource) Healthy() error {
+	return CheckHealth(ds.source)
+}
+
+// debounce returns a function that calls handler once the interval has passed since the first
+// of a burst of calls to it. Calls made while a call to handler is pending are dropped.
+// The pending call is dropped if the context is done. A non-positive interval returns handler itself.
+func debounce(ctx context.Context, interval time.Duration, handler func()) func() {
+	if interval <= 0 {
+		return handler
+	}
+	var (
+		mu      sync.Mutex
+		pending bool
+	)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if pending {
+			return
+		}
+		pending = true
+		time.AfterFunc(interval, func() {
+			mu.Lock()
+			pending = false
+			mu.Unlock()
+			if ctx.Err() == nil {
+				handler()
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that debounceSource is a Source
+var _ Source = &debounceSource{}
+
+// handlerCapturingSource is a Source that keeps the last event handler added to it.
+type handlerCapturingSource struct {
+	handler func()
+}
+
+func (s *handlerCapturingSource) Endpoints(context.Context) ([]*endpoint.Endpoint, error) {
+	return nil, nil
+}
+
+func (s *handlerCapturingSource) AddEventHandler(_ context.Context, handler func()) {
+	s.handler = handler
+}
+
+func TestDebounceSource(t *testing.T) {
+	t.Run("Burst", testDebounceSourceBurst)
+	t.Run("Cancelled", testDebounceSourceCancelled)
+	t.Run("Disabled", testDebounceSourceDisabled)
+}
+
+// testDebounceSourceBurst tests that a burst of events calls the handler once.
+func testDebounceSourceBurst(t *testing.T) {
+	inner := &handlerCapturingSource{}
+	var calls int32
+	NewDebounceSource(inner, 50*time.Millisecond).AddEventHandler(context.Background(), func() {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	for i := 0; i < 10; i++ {
+		inner.handler()
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// A later event calls the handler again.
+	inner.handler()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+// testDebounceSourceCancelled tests that a pending call is dropped once the context is done.
+func testDebounceSourceCancelled(t *testing.T) {
+	inner := &handlerCapturingSource{}
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	NewDebounceSource(inner, 50*time.Millisecond).AddEventHandler(ctx, func() {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	inner.handler()
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+}
+
+// testDebounceSourceDisabled tests that a zero interval calls the handler on every event.
+func testDebounceSourceDisabled(t *testing.T) {
+	inner := &handlerCapturingSource{}
+	var calls int32
+	NewDebounceSource(inner, 0).AddEventHandler(context.Background(), func() {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	for i := 0; i < 10; i++ {
+		inner.handler()
+	}
+	assert.EqualValues(t, 10, atomic.LoadInt32(&calls))
+}
@@ -36,13 +36,41 @@ func NewDedupSource(source Source) Source {
 
 // Endpoints collects endpoints from its wrapped source and returns them without duplicates.
 func (ms *dedupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	result := []*endpoint.Endpoint{}
-	collected := map[string]bool{}
-
 	endpoints, err := ms.source.Endpoints(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return dedupEndpoints(endpoints), nil
+}
+
+// EndpointsWithWarnings collects endpoints and warnings from its wrapped source and returns the
+// endpoints without duplicates.
+func (ms *dedupSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	endpoints, warnings, err := EndpointsWithWarnings(ctx, ms.source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dedupEndpoints(endpoints), warnings, nil
+}
+
+func (ms *dedupSource) AddEventHandler(ctx context.Context, handler func()) {
+	ms.source.AddEventHandler(ctx, handler)
+}
+
+// Close closes the wrapped source if it can be closed.
+func (ms *dedupSource) Close() error {
+	return closeSource(ms.source)
+}
+
+// Healthy reports the health of the wrapped source.
+func (ms *dedupSource) Healthy() error {
+	return CheckHealth(ms.source)
+}
+
+// dedupEndpoints returns the endpoints without those equal in name, set identifier and targets to a previous one.
+func dedupEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	result := []*endpoint.Endpoint{}
+	collected := map[string]bool{}
 
 	for _, ep := range endpoints {
 		identifier := ep.DNSName + " / " + ep.SetIdentifier + " / " + ep.Targets.String()
@@ -56,9 +84,5 @@ func (ms *dedupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, err
 		result = append(result, ep)
 	}
 
-	return result, nil
-}
-
-func (ms *dedupSource) AddEventHandler(ctx context.Context, handler func()) {
-	ms.source.AddEventHandler(ctx, handler)
+	return result
 }
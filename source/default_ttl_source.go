@@ -0,0 +1,33 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// SetDefaultTTL returns an EndpointModifier setting the given TTL on the endpoints which don't have a TTL configured.
+func SetDefaultTTL(ttl endpoint.TTL) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		for _, ep := range endpoints {
+			if !ep.RecordTTL.IsConfigured() {
+				ep.RecordTTL = ttl
+			}
+		}
+		return endpoints
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestDefaultTTLSource(t *testing.T) {
+	t.Run("Endpoints", testDefaultTTLEndpoints)
+	t.Run("ByNames", testDefaultTTLByNames)
+}
+
+// testDefaultTTLEndpoints tests that the default TTL only applies to endpoints without a TTL.
+func testDefaultTTLEndpoints(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 60},
+	}, nil)
+
+	endpoints, err := NewModifiedSource(mockSource, SetDefaultTTL(300)).Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 300},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 60},
+	})
+	mockSource.AssertExpectations(t)
+}
+
+// testDefaultTTLByNames tests that sources built by name get the configured default TTL.
+func testDefaultTTLByNames(t *testing.T) {
+	kubeClient := fakeKube.NewSimpleClientset()
+	for _, svc := range []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "foo",
+				Annotations: map[string]string{hostnameAnnotationKey: "foo.example.org"},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "bar",
+				Annotations: map[string]string{
+					hostnameAnnotationKey: "bar.example.org",
+					ttlAnnotationKey:      "60",
+				},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.5"}}},
+			},
+		},
+	} {
+		_, err := kubeClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(kubeClient, nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"service"}, &Config{DefaultTTL: 5 * time.Minute})
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	endpoints, err := sources[0].Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordTTL: 300},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.5"}, RecordTTL: 60},
+	})
+}
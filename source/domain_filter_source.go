@@ -0,0 +1,38 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// FilterDomains returns an EndpointModifier dropping the endpoints whose names are outside of the allowed domains.
+func FilterDomains(domainFilter endpoint.DomainFilter) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		result := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			if !domainFilter.Match(ep.DNSName) {
+				log.Warnf("Dropping endpoint %s of resource %q because it is outside of the allowed domains", ep.DNSName, ep.Labels[endpoint.ResourceLabelKey])
+				continue
+			}
+			result = append(result, ep)
+		}
+		return result
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestDomainFilterSource(t *testing.T) {
+	t.Run("Endpoints", testDomainFilterEndpoints)
+	t.Run("ByNames", testDomainFilterByNames)
+}
+
+// testDomainFilterEndpoints tests that endpoints outside of the allowed domains are dropped.
+func testDomainFilterEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title          string
+		domainFilter   []string
+		excludeDomains []string
+		expected       []*endpoint.Endpoint
+	}{
+		{
+			title:        "allowed domain",
+			domainFilter: []string{"example.org"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+		{
+			title:          "allowed domain with excluded subdomain",
+			domainFilter:   []string{"example.org"},
+			excludeDomains: []string{"bar.foo.example.org"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+		{
+			title:          "excluded domain only",
+			excludeDomains: []string{"example.org"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.com", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "bar.foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "foo.example.com", Targets: endpoint.Targets{"1.2.3.4"}},
+			}, nil)
+
+			domainFilter := endpoint.NewDomainFilterWithExclusions(tc.domainFilter, tc.excludeDomains)
+			endpoints, err := NewModifiedSource(mockSource, FilterDomains(domainFilter)).Endpoints(context.Background())
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, tc.expected)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
+
+// testDomainFilterByNames tests that an out-of-zone hostname annotation of a source built by name is filtered out.
+func testDomainFilterByNames(t *testing.T) {
+	dynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:   "default",
+		name:        "fake",
+		host:        "foo.example.org",
+		annotations: map[string]string{hostnameAnnotationKey: "foo.example.com"},
+		loadBalancer: fakeLoadBalancerService{
+			ips: []string{"8.8.8.8"},
+		},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = dynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fake.NewSimpleClientset(), nil)
+	mockClientGenerator.On("DynamicKubernetesClient").Return(dynamicClient, nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"contour-httpproxy"}, &Config{DomainFilter: []string{"example.org"}})
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	endpoints, err := sources[0].Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}},
+	})
+}
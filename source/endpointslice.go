@@ -0,0 +1,335 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1beta1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// endpointSliceSource is an implementation of Source for the EndpointSlices of headless services.
+// For each headless service under our jurisdiction, i.e. with a hostname annotation or an FQDN template
+// and a matching or no controller annotation, it returns an A or AAAA record for every pod backing the
+// service, named after the hostname of the pod, or else the pod itself, under the hostname of the service.
+type endpointSliceSource struct {
+	client                   kubernetes.Interface
+	namespace                string
+	annotationFilter         string
+	fqdnTemplate             *template.Template
+	ignoreHostnameAnnotation bool
+	serviceInformer          coreinformers.ServiceInformer
+	endpointSliceInformer    discoveryinformers.EndpointSliceInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
+}
+
+// NewEndpointSliceSource creates a new endpointSliceSource with the given config.
+func NewEndpointSliceSource(kubeClient kubernetes.Interface, namespace, annotationFilter, fqdnTemplate string, ignoreHostnameAnnotation bool, cacheSyncTimeout time.Duration) (Source, error) {
+	tmpl, err := parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use shared informers to listen for add/update/delete of services and endpointslices in the specified namespace.
+	// Set resync period to 0, to prevent processing when nothing has changed
+	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
+	serviceInformer := informerFactory.Core().V1().Services()
+	endpointSliceInformer := informerFactory.Discovery().V1beta1().EndpointSlices()
+
+	// Add default resource event handlers to properly initialize informer.
+	serviceInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+	endpointSliceInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
+
+	// wait for the local cache to be populated.
+	err = waitForCacheSync("endpointslice", "Service", cacheSyncTimeout, serviceInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
+	}
+	err = waitForCacheSync("endpointslice", "EndpointSlice", cacheSyncTimeout, endpointSliceInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
+	}
+
+	return &endpointSliceSource{
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("endpointslice", serviceInformer.Informer().HasSynced, endpointSliceInformer.Informer().HasSynced),
+		client:                   kubeClient,
+		namespace:                namespace,
+		annotationFilter:         annotationFilter,
+		fqdnTemplate:             tmpl,
+		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
+		serviceInformer:          serviceInformer,
+		endpointSliceInformer:    endpointSliceInformer,
+	}, nil
+}
+
+// Endpoints returns endpoint objects for each pod backing a headless service that should be processed.
+func (sc *endpointSliceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("endpointslice")
+
+	services, err := sc.serviceInformer.Lister().Services(sc.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for i, svc := range services {
+		if annotations, ok := sc.translateAnnotations(svc.Annotations); ok {
+			svc = svc.DeepCopy()
+			svc.Annotations = annotations
+			services[i] = svc
+		}
+	}
+	services, err = sc.filterByAnnotations(services)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+
+	for _, svc := range services {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if svc.Spec.ClusterIP != v1.ClusterIPNone {
+			continue
+		}
+
+		// Check controller annotation to see if we are responsible.
+		controller, ok := svc.Annotations[controllerAnnotationKey]
+		if ok && controller != controllerAnnotationValue {
+			log.Debugf("Skipping service %s/%s because controller value does not match, found: %s, required: %s",
+				svc.Namespace, svc.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+
+		hostnames, err := sc.hostnames(svc)
+		if err != nil {
+			return nil, err
+		}
+		if len(hostnames) == 0 {
+			log.Debugf("Skipping service %s/%s because it has no hostname", svc.Namespace, svc.Name)
+			metrics.skip(skipReasonNoAnnotation)
+			continue
+		}
+
+		svcEndpoints, err := sc.endpointsFromService(svc, hostnames)
+		if err != nil {
+			return nil, err
+		}
+		if len(svcEndpoints) == 0 {
+			log.Debugf("No endpoints could be generated from service %s/%s", svc.Namespace, svc.Name)
+			metrics.skip(skipReasonNoEndpoints)
+			continue
+		}
+
+		log.Debugf("Endpoints generated from service: %s/%s: %v", svc.Namespace, svc.Name, svcEndpoints)
+		for _, ep := range svcEndpoints {
+			ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
+		}
+		setOwnerIDLabel(svc.Annotations, svcEndpoints)
+		endpoints = append(endpoints, svcEndpoints...)
+	}
+
+	metrics.publish(endpoints)
+	return endpoints, nil
+}
+
+// hostnames returns the hostnames of the service from its hostname annotation, or else from the FQDN template.
+func (sc *endpointSliceSource) hostnames(svc *v1.Service) ([]string, error) {
+	var hostnames []string
+	if !sc.ignoreHostnameAnnotation {
+		hostnames = getHostnamesFromAnnotations(svc.Annotations)
+	}
+	if len(hostnames) == 0 && sc.fqdnTemplate != nil {
+		var buf bytes.Buffer
+		if err := sc.fqdnTemplate.Execute(&buf, svc); err != nil {
+			return nil, fmt.Errorf("failed to apply template on service %s/%s: %v", svc.Namespace, svc.Name, err)
+		}
+		hostnames = strings.Split(strings.Replace(buf.String(), " ", "", -1), ",")
+	}
+
+	var result []string
+	for _, hostname := range hostnames {
+		if hostname = strings.TrimSuffix(hostname, "."); hostname != "" {
+			result = append(result, hostname)
+		}
+	}
+	return result, nil
+}
+
+// endpointsFromService returns an endpoint for each pod backing the service under each of the given hostnames.
+// Addresses which are not ready are only published if the service publishes not ready addresses.
+func (sc *endpointSliceSource) endpointsFromService(svc *v1.Service, hostnames []string) ([]*endpoint.Endpoint, error) {
+	selector := labels.SelectorFromSet(labels.Set{discovery.LabelServiceName: svc.Name})
+	slices, err := sc.endpointSliceInformer.Lister().EndpointSlices(svc.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := getTTLFromAnnotations(svc.Annotations)
+	if err != nil {
+		log.Warn(err)
+	}
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(svc.Annotations)
+
+	type recordKey struct {
+		dnsName    string
+		recordType string
+	}
+	targets := map[recordKey]endpoint.Targets{}
+	for _, slice := range slices {
+		var recordType string
+		switch slice.AddressType {
+		case discovery.AddressTypeIPv4:
+			recordType = endpoint.RecordTypeA
+		case discovery.AddressTypeIPv6:
+			recordType = endpoint.RecordTypeAAAA
+		default:
+			log.Debugf("Skipping EndpointSlice %s/%s because of its address type %s", slice.Namespace, slice.Name, slice.AddressType)
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready && !svc.Spec.PublishNotReadyAddresses {
+				continue
+			}
+
+			var name string
+			switch {
+			case ep.Hostname != nil && *ep.Hostname != "":
+				name = *ep.Hostname
+			case ep.TargetRef != nil && ep.TargetRef.Kind == "Pod":
+				name = ep.TargetRef.Name
+			default:
+				log.Debugf("Skipping endpoint %v of EndpointSlice %s/%s because it has neither a hostname nor a pod", ep.Addresses, slice.Namespace, slice.Name)
+				continue
+			}
+
+			for _, hostname := range hostnames {
+				key := recordKey{dnsName: name + "." + hostname, recordType: recordType}
+				targets[key] = append(targets[key], ep.Addresses...)
+			}
+		}
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(targets))
+	for key, keyTargets := range targets {
+		sort.Sort(keyTargets)
+		ep := endpoint.NewEndpointWithTTL(key.dnsName, key.recordType, ttl, keyTargets...)
+		ep.ProviderSpecific = providerSpecific
+		ep.SetIdentifier = setIdentifier
+		endpoints = append(endpoints, ep)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].DNSName != endpoints[j].DNSName {
+			return endpoints[i].DNSName < endpoints[j].DNSName
+		}
+		return endpoints[i].RecordType < endpoints[j].RecordType
+	})
+	return endpoints, nil
+}
+
+// filterByAnnotations filters a list of services by a given annotation selector.
+func (sc *endpointSliceSource) filterByAnnotations(services []*v1.Service) ([]*v1.Service, error) {
+	labelSelector, err := metav1.ParseToLabelSelector(sc.annotationFilter)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty filter returns original list
+	if selector.Empty() {
+		return services, nil
+	}
+
+	filteredList := []*v1.Service{}
+
+	for _, service := range services {
+		// convert the service's annotations to an equivalent label selector
+		annotations := labels.Set(service.Annotations)
+
+		// include service if its annotations match the selector
+		if selector.Matches(annotations) {
+			filteredList = append(filteredList, service)
+		}
+	}
+
+	return filteredList, nil
+}
+
+func (sc *endpointSliceSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for endpointslice")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	for _, informer := range []cache.SharedIndexInformer{sc.serviceInformer.Informer(), sc.endpointSliceInformer.Informer()} {
+		informer.AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					handler()
+				},
+				UpdateFunc: func(old interface{}, new interface{}) {
+					handler()
+				},
+				DeleteFunc: func(obj interface{}) {
+					handler()
+				},
+			},
+		)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestEndpointSliceSource(t *testing.T) {
+	t.Run("Interface", testEndpointSliceSourceImplementsSource)
+	t.Run("Endpoints", testEndpointSliceSourceEndpoints)
+	t.Run("FQDNTemplate", testEndpointSliceSourceFQDNTemplate)
+}
+
+// testEndpointSliceSourceImplementsSource tests that endpointSliceSource is a valid Source.
+func testEndpointSliceSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(endpointSliceSource))
+}
+
+func testEndpointSliceSourceEndpoints(t *testing.T) {
+	kubeClient := fakeKube.NewSimpleClientset()
+	createHeadlessService(t, kubeClient, "headless", v1.ClusterIPNone, map[string]string{
+		hostnameAnnotationKey: "svc.example.org",
+		ttlAnnotationKey:      "60",
+	})
+	createHeadlessService(t, kubeClient, "clusterip", "10.0.0.1", map[string]string{
+		hostnameAnnotationKey: "clusterip.example.org",
+	})
+	createHeadlessService(t, kubeClient, "other-controller", v1.ClusterIPNone, map[string]string{
+		hostnameAnnotationKey:   "other.example.org",
+		controllerAnnotationKey: "some-other-tool",
+	})
+	createEndpointSlice(t, kubeClient, "headless-ipv4", "headless", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		fakeSliceEndpoint("10.1.0.1", "web-0", "web-0", true),
+		fakeSliceEndpoint("10.1.0.2", "", "web-1", true),
+		fakeSliceEndpoint("10.1.0.3", "", "web-2", false),
+		fakeSliceEndpoint("10.1.0.4", "", "", true),
+	})
+	createEndpointSlice(t, kubeClient, "headless-ipv6", "headless", discovery.AddressTypeIPv6, []discovery.Endpoint{
+		fakeSliceEndpoint("2001:db8::1", "web-0", "web-0", true),
+	})
+	createEndpointSlice(t, kubeClient, "clusterip-ipv4", "clusterip", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		fakeSliceEndpoint("10.2.0.1", "", "api-0", true),
+	})
+	createEndpointSlice(t, kubeClient, "other-controller-ipv4", "other-controller", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		fakeSliceEndpoint("10.3.0.1", "", "other-0", true),
+	})
+
+	src, err := NewEndpointSliceSource(kubeClient, "", "", "", false, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "web-0.svc.example.org", Targets: endpoint.Targets{"10.1.0.1"}, RecordType: endpoint.RecordTypeA, RecordTTL: 60},
+		{DNSName: "web-0.svc.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 60},
+		{DNSName: "web-1.svc.example.org", Targets: endpoint.Targets{"10.1.0.2"}, RecordType: endpoint.RecordTypeA, RecordTTL: 60},
+	})
+	for _, ep := range endpoints {
+		assert.Equal(t, "service/default/headless", ep.Labels[endpoint.ResourceLabelKey])
+	}
+}
+
+func testEndpointSliceSourceFQDNTemplate(t *testing.T) {
+	kubeClient := fakeKube.NewSimpleClientset()
+	createHeadlessService(t, kubeClient, "headless", v1.ClusterIPNone, map[string]string{
+		hostnameAnnotationKey: "ignored.example.org",
+	})
+	createEndpointSlice(t, kubeClient, "headless-ipv4", "headless", discovery.AddressTypeIPv4, []discovery.Endpoint{
+		fakeSliceEndpoint("10.1.0.1", "", "web-0", true),
+	})
+
+	src, err := NewEndpointSliceSource(kubeClient, "", "", "{{.Name}}.{{.Namespace}}.example.org", true, 0)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "web-0.headless.default.example.org", Targets: endpoint.Targets{"10.1.0.1"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func createHeadlessService(t *testing.T, kubeClient *fakeKube.Clientset, name, clusterIP string, annotations map[string]string) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: clusterIP,
+		},
+	}
+	_, err := kubeClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func createEndpointSlice(t *testing.T, kubeClient *fakeKube.Clientset, name, service string, addressType discovery.AddressType, endpoints []discovery.Endpoint) {
+	slice := &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels:    map[string]string{discovery.LabelServiceName: service},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+	}
+	_, err := kubeClient.DiscoveryV1beta1().EndpointSlices(slice.Namespace).Create(context.Background(), slice, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func fakeSliceEndpoint(address, hostname, pod string, ready bool) discovery.Endpoint {
+	ep := discovery.Endpoint{
+		Addresses:  []string{address},
+		Conditions: discovery.EndpointConditions{Ready: &ready},
+	}
+	if hostname != "" {
+		ep.Hostname = &hostname
+	}
+	if pod != "" {
+		ep.TargetRef = &v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: pod}
+	}
+	return ep
+}
@@ -66,6 +66,7 @@ func (sc *fakeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 		endpoints[i], _ = sc.generateEndpoint()
 	}
 
+	newSourceMetrics("fake").publish(endpoints)
 	return endpoints, nil
 }
 
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// fileSource is an implementation of Source that reads endpoints from a YAML or JSON file
+// holding a list of endpoints, e.g. to manage records declaratively next to cluster sources.
+type fileSource struct {
+	path string
+	*stopper
+}
+
+// NewFileSource creates a new fileSource reading the endpoints from the given path.
+func NewFileSource(path string) (Source, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file source requires a path")
+	}
+	return &fileSource{
+		path:    filepath.Clean(path),
+		stopper: newStopper(),
+	}, nil
+}
+
+// Endpoints returns the endpoints listed in the file.
+func (fs *fileSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	data, err := ioutil.ReadFile(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+	if err := yaml.Unmarshal(data, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse endpoints of file %s: %v", fs.path, err)
+	}
+
+	for i, ep := range endpoints {
+		if ep == nil || ep.DNSName == "" || len(ep.Targets) == 0 {
+			return nil, fmt.Errorf("invalid endpoint %d of file %s: a DNS name and targets are required", i, fs.path)
+		}
+		if ep.RecordType == "" {
+			ep.RecordType = suitableTypeWithIPv6(ep.Targets[0])
+		}
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		ep.Labels[endpoint.ResourceLabelKey] = "file/" + fs.path
+	}
+
+	log.Debugf("Endpoints read from file %s: %v", fs.path, endpoints)
+	newSourceMetrics("file").publish(endpoints)
+	return endpoints, nil
+}
+
+// AddEventHandler calls the handler whenever the file changes until the context is done or the source is closed.
+// The directory of the file is watched, so that files which are replaced rather than written are followed.
+func (fs *fileSource) AddEventHandler(ctx context.Context, handler func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("Failed to watch file %s: %v", fs.path, err)
+		return
+	}
+	if err := watcher.Add(filepath.Dir(fs.path)); err != nil {
+		log.Errorf("Failed to watch file %s: %v", fs.path, err)
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-fs.stopCh:
+				return
+			case event := <-watcher.Events:
+				if filepath.Clean(event.Name) == fs.path {
+					handler()
+				}
+			case err := <-watcher.Errors:
+				log.Warnf("Failed to watch file %s: %v", fs.path, err)
+			}
+		}
+	}()
+}
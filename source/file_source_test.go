@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestFileSource(t *testing.T) {
+	t.Run("Interface", testFileSourceImplementsSource)
+	t.Run("Endpoints", testFileSourceEndpoints)
+	t.Run("AddEventHandler", testFileSourceAddEventHandler)
+}
+
+// testFileSourceImplementsSource tests that fileSource is a valid Source.
+func testFileSourceImplementsSource(t *testing.T) {
+	assert.Implements(t, (*Source)(nil), new(fileSource))
+}
+
+// testFileSourceEndpoints tests that the endpoints of YAML and JSON files are returned.
+func testFileSourceEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		content     string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title: "yaml",
+			content: `
+- dnsName: foo.example.org
+  targets: ["1.2.3.4", "1.2.3.5"]
+  recordTTL: 60
+- dnsName: bar.example.org
+  targets: ["lb.example.com"]
+  recordType: CNAME
+  labels:
+    team: dns
+`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4", "1.2.3.5"}, RecordType: endpoint.RecordTypeA, RecordTTL: 60},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title:   "json",
+			content: `[{"dnsName": "foo.example.org", "targets": ["1.2.3.4"], "recordType": "A"}]`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "ipv6",
+			content: `
+- dnsName: foo.example.org
+  targets: ["2001:db8::1"]
+- dnsName: bar.example.org
+  targets: ["1.2.3.4"]
+`,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:    "empty",
+			content:  "",
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:       "malformed",
+			content:     "dnsName: foo.example.org",
+			expectError: true,
+		},
+		{
+			title:       "missing targets",
+			content:     "- dnsName: foo.example.org",
+			expectError: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			path := writeEndpointsFile(t, tc.content)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			src, err := NewFileSource(path)
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+			for _, ep := range endpoints {
+				assert.Equal(t, "file/"+path, ep.Labels[endpoint.ResourceLabelKey])
+			}
+		})
+	}
+
+	src, err := NewFileSource(filepath.Join(os.TempDir(), "missing-endpoints.yaml"))
+	require.NoError(t, err)
+	_, err = src.Endpoints(context.Background())
+	assert.Error(t, err, "should fail to read a missing file")
+
+	_, err = NewFileSource("")
+	assert.Error(t, err, "should require a path")
+}
+
+// testFileSourceAddEventHandler tests that the handler is called once the file changes.
+func testFileSourceAddEventHandler(t *testing.T) {
+	path := writeEndpointsFile(t, "[]")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	src, err := NewFileSource(path)
+	require.NoError(t, err)
+	defer src.(*fileSource).Close()
+
+	called := make(chan struct{}, 1)
+	src.AddEventHandler(context.Background(), func() {
+		select {
+		case called <- struct{}{}:
+		default:
+		}
+	})
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"dnsName": "foo.example.org", "targets": ["1.2.3.4"]}]`), 0644))
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler should be called once the file changes")
+	}
+}
+
+func writeEndpointsFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "file-source")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "endpoints.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
@@ -32,7 +32,6 @@ import (
 	networkingv1alpha3informer "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -54,6 +53,9 @@ type gatewaySource struct {
 	ignoreHostnameAnnotation bool
 	serviceInformer          coreinformers.ServiceInformer
 	gatewayInformer          networkingv1alpha3informer.GatewayInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewIstioGatewaySource creates a new gatewaySource with the given config.
@@ -65,19 +67,16 @@ func NewIstioGatewaySource(
 	fqdnTemplate string,
 	combineFQDNAnnotation bool,
 	ignoreHostnameAnnotation bool,
+	cacheSyncTimeout time.Duration,
 ) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
 
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informers to listen for add/update/delete of services/pods/nodes in the specified namespace.
@@ -104,27 +103,28 @@ func NewIstioGatewaySource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
-	istioInformerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
+	istioInformerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return serviceInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("istio-gateway", "Service", cacheSyncTimeout, serviceInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return gatewayInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("istio-gateway", "Gateway", cacheSyncTimeout, gatewayInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	return &gatewaySource{
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("istio-gateway", serviceInformer.Informer().HasSynced, gatewayInformer.Informer().HasSynced),
 		kubeClient:               kubeClient,
 		istioClient:              istioClient,
 		namespace:                namespace,
@@ -140,12 +140,19 @@ func NewIstioGatewaySource(
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all gateway resources in the source's namespace(s).
 func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("istio-gateway")
+
 	gwList, err := sc.istioClient.NetworkingV1alpha3().Gateways(sc.namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	gateways := gwList.Items
+	for i := range gateways {
+		if annotations, ok := sc.translateAnnotations(gateways[i].Annotations); ok {
+			gateways[i].Annotations = annotations
+		}
+	}
 	gateways, err = sc.filterByAnnotations(gateways)
 	if err != nil {
 		return nil, err
@@ -159,6 +166,12 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping gateway %s/%s because controller value does not match, found: %s, required: %s",
 				gateway.Namespace, gateway.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(gateway.Annotations); err != nil {
+			log.Warnf("Skipping gateway %s/%s: %v", gateway.Namespace, gateway.Name, err)
+			metrics.skip(skipReasonInvalid)
 			continue
 		}
 
@@ -183,6 +196,7 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		if len(gwHostnames) == 0 {
 			log.Debugf("No hostnames could be generated from gateway %s/%s", gateway.Namespace, gateway.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
@@ -193,6 +207,7 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		log.Debugf("Endpoints generated from gateway: %s/%s: %v", gateway.Namespace, gateway.Name, gwEndpoints)
 		sc.setResourceLabel(gateway, gwEndpoints)
+		setOwnerIDLabel(gateway.Annotations, gwEndpoints)
 		endpoints = append(endpoints, gwEndpoints...)
 	}
 
@@ -200,6 +215,7 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
@@ -308,9 +324,10 @@ func (sc *gatewaySource) endpointsFromGateway(hostnames []string, gateway networ
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(annotations)
+	recordType := getRecordTypeFromAnnotations(annotations)
 
 	for _, host := range hostnames {
-		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	return endpoints, nil
@@ -77,6 +77,7 @@ func (suite *GatewaySuite) SetupTest() {
 		"{{.Name}}",
 		false,
 		false,
+		0,
 	)
 	suite.NoError(err, "should initialize gateway source")
 	suite.NoError(err, "should succeed")
@@ -143,6 +144,7 @@ func TestNewIstioGatewaySource(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				false,
+				0,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -1157,6 +1159,7 @@ func testGatewayEndpoints(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				ti.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -1193,6 +1196,7 @@ func newTestGatewaySource(loadBalancerList []fakeIngressGatewayService) (*gatewa
 		"{{.Name}}",
 		false,
 		false,
+		0,
 	)
 	if err != nil {
 		return nil, err
@@ -21,64 +21,94 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
-	"time"
 
 	"github.com/pkg/errors"
 	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
 	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
+const (
+	// contourIngressClassAnnotationKey is the annotation declaring the ingress class of resources in newer versions of Contour.
+	contourIngressClassAnnotationKey = "projectcontour.io/ingress.class"
+	// heptioIngressClassAnnotationKey is the annotation declaring the ingress class of resources in older versions of Contour.
+	heptioIngressClassAnnotationKey = "contour.heptio.com/ingress.class"
+)
+
 // HTTPProxySource is an implementation of Source for ProjectContour HTTPProxy objects.
 // The HTTPProxy implementation uses the spec.virtualHost.fqdn value for the hostname.
 // Use targetAnnotationKey to explicitly set Endpoint.
 type httpProxySource struct {
 	dynamicKubeClient        dynamic.Interface
+	kubeClient               kubernetes.Interface
 	namespace                string
 	annotationFilter         string
+	labelSelector            labels.Selector
 	fqdnTemplate             *template.Template
+	fqdnSuffix               string
+	emitWWWAlias             bool
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	replaceFQDNWithHostnames bool
+	acceptConditions         bool
+	publishInvalid           bool
+	routeWeightsToDNS        bool
+	targetLookupRetries      int
+	ingressClass             string
 	httpProxyInformer        informers.GenericInformer
 	unstructuredConverter    *UnstructuredConverter
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewContourHTTPProxySource creates a new contourHTTPProxySource with the given config.
-func NewContourHTTPProxySource(
-	dynamicKubeClient dynamic.Interface,
-	namespace string,
-	annotationFilter string,
-	fqdnTemplate string,
-	combineFqdnAnnotation bool,
-	ignoreHostnameAnnotation bool,
-) (Source, error) {
+func NewContourHTTPProxySource(dynamicKubeClient dynamic.Interface, kubeClient kubernetes.Interface, cfg *Config) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(cfg.FQDNTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	labelSelector, err := labels.Parse(cfg.LabelFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	replaceFQDNWithHostnames, err := parseHostnameAnnotationStrategy(cfg.HostnameAnnotationStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	annotationPrefix, err := parseAnnotationPrefix(cfg.AnnotationPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	uc, err := NewUnstructuredConverter()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup Unstructured Converter")
 	}
 
 	// Use shared informer to listen for add/update/delete of HTTPProxys in the specified namespace.
 	// Set resync period to 0, to prevent processing when nothing has changed.
-	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, cfg.Namespace, nil)
 	httpProxyInformer := informerFactory.ForResource(projectcontour.HTTPProxyGVR)
 
 	// Add default resource event handlers to properly initialize informer.
@@ -89,29 +119,37 @@ func NewContourHTTPProxySource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return httpProxyInformer.Informer().HasSynced(), nil
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to sync cache")
-	}
-
-	uc, err := NewUnstructuredConverter()
+	err = waitForCacheSync("contour-httpproxy", "HTTPProxy", cfg.CacheSyncTimeout, httpProxyInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to setup Unstructured Converter")
+		stop.Close()
+		return nil, err
 	}
 
 	return &httpProxySource{
+		annotationPrefixer:       annotationPrefixer{annotationPrefix: annotationPrefix},
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("contour-httpproxy", httpProxyInformer.Informer().HasSynced),
 		dynamicKubeClient:        dynamicKubeClient,
-		namespace:                namespace,
-		annotationFilter:         annotationFilter,
+		kubeClient:               kubeClient,
+		namespace:                cfg.Namespace,
+		annotationFilter:         cfg.AnnotationFilter,
+		labelSelector:            labelSelector,
 		fqdnTemplate:             tmpl,
-		combineFQDNAnnotation:    combineFqdnAnnotation,
-		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
+		fqdnSuffix:               strings.Trim(cfg.ContourFQDNSuffix, "."),
+		emitWWWAlias:             cfg.ContourEmitWWWAlias,
+		combineFQDNAnnotation:    cfg.CombineFQDNAndAnnotation,
+		ignoreHostnameAnnotation: cfg.IgnoreHostnameAnnotation,
+		replaceFQDNWithHostnames: replaceFQDNWithHostnames,
+		acceptConditions:         cfg.ContourAcceptConditions,
+		publishInvalid:           cfg.ContourPublishInvalid,
+		routeWeightsToDNS:        cfg.ContourRouteWeightsToDNS,
+		targetLookupRetries:      cfg.TargetLookupRetries,
+		ingressClass:             cfg.ContourIngressClass,
 		httpProxyInformer:        httpProxyInformer,
 		unstructuredConverter:    uc,
 	}, nil
@@ -120,56 +158,148 @@ func NewContourHTTPProxySource(
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all HTTPProxy resources in the source's namespace(s).
 func (sc *httpProxySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, _, err := sc.EndpointsWithWarnings(ctx)
+	return endpoints, err
+}
+
+// EndpointsWithWarnings returns the endpoints of the source along with a warning for each HTTPProxy
+// it skipped, e.g. because it is not valid, has no targets or belongs to another controller.
+func (sc *httpProxySource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	metrics := newSourceMetrics("contour-httpproxy")
+
+	// Includes are resolved against all HTTPProxies before they are selected.
 	hps, err := sc.httpProxyInformer.Lister().ByNamespace(sc.namespace).List(labels.Everything())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Convert to []*projectcontour.HTTPProxy
 	var httpProxies []*projectcontour.HTTPProxy
+	// spec.ingressClassName, spec.virtualhost.fqdns and status.conditions are not part of the typed
+	// HTTPProxy, so they are read from the unstructured object.
+	ingressClassNames := make(map[string]string)
+	additionalFQDNs := make(map[string][]string)
+	validConditions := make(map[string]bool)
 	for _, hp := range hps {
 		unstructuredHP, ok := hp.(*unstructured.Unstructured)
 		if !ok {
-			return nil, errors.New("could not convert")
+			return nil, nil, errors.New("could not convert")
 		}
 
 		hpConverted := &projectcontour.HTTPProxy{}
 		err := sc.unstructuredConverter.scheme.Convert(unstructuredHP, hpConverted, nil)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to convert to HTTPProxy")
+			return nil, nil, errors.Wrap(err, "failed to convert to HTTPProxy")
 		}
+		hpConverted.Annotations = ensureAnnotations(hpConverted.Annotations)
+		hpConverted.Annotations, _ = sc.translateAnnotations(hpConverted.Annotations)
+		ingressClassNames[hpConverted.Namespace+"/"+hpConverted.Name], _, _ = unstructured.NestedString(unstructuredHP.Object, "spec", "ingressClassName")
+		additionalFQDNs[hpConverted.Namespace+"/"+hpConverted.Name] = additionalContourFQDNs(unstructuredHP)
+		validConditions[hpConverted.Namespace+"/"+hpConverted.Name] = hasValidCondition(unstructuredHP)
 		httpProxies = append(httpProxies, hpConverted)
 	}
 
-	httpProxies, err = sc.filterByAnnotations(httpProxies)
+	// Resolve includes against all HTTPProxies, regardless of the label and annotation filters.
+	byName := make(map[string]*projectcontour.HTTPProxy, len(httpProxies))
+	for _, hp := range httpProxies {
+		byName[hp.Namespace+"/"+hp.Name] = hp
+	}
+	includes := make(map[*projectcontour.HTTPProxy][]*projectcontour.HTTPProxy)
+	included := make(map[string]bool)
+	for _, hp := range httpProxies {
+		if hp.Spec.VirtualHost == nil {
+			continue
+		}
+		children := includedHTTPProxies(hp, byName)
+		for _, child := range children {
+			included[child.Namespace+"/"+child.Name] = true
+		}
+		includes[hp] = children
+	}
+
+	// An HTTPProxy selected by the label filter is published through the roots including it,
+	// even if they aren't selected themselves. Such roots don't publish their own hostnames.
+	selected := make(map[string]bool)
+	for _, hp := range sc.filterByLabels(httpProxies) {
+		selected[hp.Namespace+"/"+hp.Name] = true
+	}
+	var selectedHTTPProxies []*projectcontour.HTTPProxy
+	for _, hp := range httpProxies {
+		if selected[hp.Namespace+"/"+hp.Name] || len(selectedIncludes(includes[hp], selected)) > 0 {
+			selectedHTTPProxies = append(selectedHTTPProxies, hp)
+		}
+	}
+
+	httpProxies, err = sc.filterByAnnotations(selectedHTTPProxies)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to filter HTTPProxies")
+		return nil, nil, errors.Wrap(err, "failed to filter HTTPProxies")
 	}
 
 	endpoints := []*endpoint.Endpoint{}
+	var warnings []Warning
 
 	for _, hp := range httpProxies {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		logger := log.WithFields(log.Fields{"source": "httpproxy", "namespace": hp.Namespace, "name": hp.Name})
+		resource := fmt.Sprintf("HTTPProxy/%s/%s", hp.Namespace, hp.Name)
+
+		if hp.Spec.VirtualHost == nil && included[hp.Namespace+"/"+hp.Name] {
+			logger.Debug("Skipping HTTPProxy because it is included by a root HTTPProxy")
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
 		controller, ok := hp.Annotations[controllerAnnotationKey]
 		if ok && controller != controllerAnnotationValue {
-			log.Debugf("Skipping HTTPProxy %s/%s because controller value does not match, found: %s, required: %s",
-				hp.Namespace, hp.Name, controller, controllerAnnotationValue)
+			logger.Debugf("Skipping HTTPProxy because controller value does not match, found: %s, required: %s",
+				controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonController, Message: fmt.Sprintf("controller value %q does not match %q", controller, controllerAnnotationValue)})
 			continue
-		} else if hp.Status.CurrentStatus != "valid" {
-			log.Debugf("Skipping HTTPProxy %s/%s because it is not valid", hp.Namespace, hp.Name)
+		} else if class := httpProxyIngressClass(hp, ingressClassNames[hp.Namespace+"/"+hp.Name]); sc.ingressClass != "" && class != "" && class != sc.ingressClass {
+			logger.Debugf("Skipping HTTPProxy because ingress class does not match, found: %s, required: %s", class, sc.ingressClass)
+			metrics.skip(skipReasonController)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonController, Message: fmt.Sprintf("ingress class %q does not match %q", class, sc.ingressClass)})
+			continue
+		} else if err := checkWeightedTargetAnnotation(hp.Annotations); err != nil {
+			logger.Warnf("Skipping HTTPProxy: %v", err)
+			metrics.skip(skipReasonInvalid)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonInvalid, Message: err.Error()})
+			continue
+		} else if !sc.isValid(hp, validConditions) {
+			logger.Debug("Skipping HTTPProxy because it is not valid")
+			metrics.skip(skipReasonInvalid)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonInvalid, Message: fmt.Sprintf("status %q is not valid", hp.Status.CurrentStatus)})
 			continue
 		}
 
-		hpEndpoints, err := sc.endpointsFromHTTPProxy(hp)
+		var hpEndpoints []*endpoint.Endpoint
+		children := includes[hp]
+		if selected[hp.Namespace+"/"+hp.Name] {
+			hpEndpoints, err = sc.endpointsFromHTTPProxy(ctx, hp, additionalFQDNs[hp.Namespace+"/"+hp.Name], validConditions)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to get endpoints from HTTPProxy")
+			}
+		} else {
+			children = selectedIncludes(children, selected)
+		}
+
+		includeEndpoints, err := sc.endpointsFromIncludes(ctx, hp, children, hpEndpoints, validConditions)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to get endpoints from HTTPProxy")
+			return nil, nil, errors.Wrap(err, "failed to get endpoints from the includes of HTTPProxy")
 		}
+		hpEndpoints = append(hpEndpoints, includeEndpoints...)
 
 		// apply template if fqdn is missing on HTTPProxy
-		if (sc.combineFQDNAnnotation || len(hpEndpoints) == 0) && sc.fqdnTemplate != nil {
-			tmplEndpoints, err := sc.endpointsFromTemplate(hp)
+		if selected[hp.Namespace+"/"+hp.Name] && (sc.combineFQDNAnnotation || len(hpEndpoints) == 0) && sc.fqdnTemplate != nil {
+			tmplEndpoints, err := sc.endpointsFromTemplate(ctx, hp)
 			if err != nil {
-				return nil, errors.Wrap(err, "failed to get endpoints from template")
+				return nil, nil, errors.Wrap(err, "failed to get endpoints from template")
 			}
 
 			if sc.combineFQDNAnnotation {
@@ -179,13 +309,20 @@ func (sc *httpProxySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 			}
 		}
 
+		// The fqdn and the hostname annotation may name the same host.
+		hpEndpoints = MergeEndpointsByNameType(hpEndpoints)
+
 		if len(hpEndpoints) == 0 {
-			log.Debugf("No endpoints could be generated from HTTPProxy %s/%s", hp.Namespace, hp.Name)
+			logger.Debug("No endpoints could be generated from HTTPProxy")
+			metrics.skip(skipReasonNoEndpoints)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonNoEndpoints, Message: "no endpoints could be generated, e.g. because no hostnames or targets were found"})
 			continue
 		}
 
-		log.Debugf("Endpoints generated from HTTPProxy: %s/%s: %v", hp.Namespace, hp.Name, hpEndpoints)
+		logger.Debugf("Endpoints generated from HTTPProxy: %v", hpEndpoints)
 		sc.setResourceLabel(hp, hpEndpoints)
+		setDualstackLabel(hp.Annotations, fmt.Sprintf("HTTPProxy %s/%s", hp.Namespace, hp.Name), hpEndpoints)
+		setOwnerIDLabel(hp.Annotations, hpEndpoints)
 		endpoints = append(endpoints, hpEndpoints...)
 	}
 
@@ -193,10 +330,11 @@ func (sc *httpProxySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 		sort.Sort(ep.Targets)
 	}
 
-	return endpoints, nil
+	metrics.publish(endpoints)
+	return endpoints, warnings, nil
 }
 
-func (sc *httpProxySource) endpointsFromTemplate(httpProxy *projectcontour.HTTPProxy) ([]*endpoint.Endpoint, error) {
+func (sc *httpProxySource) endpointsFromTemplate(ctx context.Context, httpProxy *projectcontour.HTTPProxy) ([]*endpoint.Endpoint, error) {
 	// Process the whole template string
 	var buf bytes.Buffer
 	err := sc.fqdnTemplate.Execute(&buf, httpProxy)
@@ -211,31 +349,43 @@ func (sc *httpProxySource) endpointsFromTemplate(httpProxy *projectcontour.HTTPP
 		log.Warn(err)
 	}
 
-	targets := getTargetsFromTargetAnnotation(httpProxy.Annotations)
-
-	if len(targets) == 0 {
-		for _, lb := range httpProxy.Status.LoadBalancer.Ingress {
-			if lb.IP != "" {
-				targets = append(targets, lb.IP)
-			}
-			if lb.Hostname != "" {
-				targets = append(targets, lb.Hostname)
-			}
-		}
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
+	recordType := getRecordTypeFromAnnotations(httpProxy.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping HTTPProxy %s/%s: %v", httpProxy.Namespace, httpProxy.Name, err)
+		return nil, nil
 	}
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
+	hostnameEndpoints, err := sc.hostnameEndpointsFunc(ctx, httpProxy, ttl, providerSpecific, setIdentifier, recordType)
+	if err != nil {
+		return nil, err
+	}
 
 	var endpoints []*endpoint.Endpoint
 	// splits the FQDN template and removes the trailing periods
 	hostnameList := strings.Split(strings.Replace(hostnames, " ", "", -1), ",")
 	for _, hostname := range hostnameList {
 		hostname = strings.TrimSuffix(hostname, ".")
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, hostnameEndpoints(hostname)...)
 	}
 	return endpoints, nil
 }
 
+// filterByLabels filters a list of HTTPProxies by the label selector of the source.
+func (sc *httpProxySource) filterByLabels(httpProxies []*projectcontour.HTTPProxy) []*projectcontour.HTTPProxy {
+	if sc.labelSelector.Empty() {
+		return httpProxies
+	}
+
+	filteredList := []*projectcontour.HTTPProxy{}
+	for _, httpProxy := range httpProxies {
+		if sc.labelSelector.Matches(labels.Set(httpProxy.Labels)) {
+			filteredList = append(filteredList, httpProxy)
+		}
+	}
+	return filteredList
+}
+
 // filterByAnnotations filters a list of configs by a given annotation selector.
 func (sc *httpProxySource) filterByAnnotations(httpProxies []*projectcontour.HTTPProxy) ([]*projectcontour.HTTPProxy, error) {
 	labelSelector, err := metav1.ParseToLabelSelector(sc.annotationFilter)
@@ -273,9 +423,10 @@ func (sc *httpProxySource) setResourceLabel(httpProxy *projectcontour.HTTPProxy,
 	}
 }
 
-// endpointsFromHTTPProxyConfig extracts the endpoints from a Contour HTTPProxy object
-func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTPProxy) ([]*endpoint.Endpoint, error) {
-	if httpProxy.Status.CurrentStatus != "valid" {
+// endpointsFromHTTPProxyConfig extracts the endpoints from a Contour HTTPProxy object.
+// The additional fqdns are published along with the fqdn of its virtual host.
+func (sc *httpProxySource) endpointsFromHTTPProxy(ctx context.Context, httpProxy *projectcontour.HTTPProxy, additionalFQDNs []string, validConditions map[string]bool) ([]*endpoint.Endpoint, error) {
+	if !sc.isValid(httpProxy, validConditions) {
 		log.Warn(errors.Errorf("cannot generate endpoints for HTTPProxy with status %s", httpProxy.Status.CurrentStatus))
 		return nil, nil
 	}
@@ -287,8 +438,72 @@ func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTP
 		log.Warn(err)
 	}
 
-	targets := getTargetsFromTargetAnnotation(httpProxy.Annotations)
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
+	recordType := getRecordTypeFromAnnotations(httpProxy.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping HTTPProxy %s/%s: %v", httpProxy.Namespace, httpProxy.Name, err)
+		return nil, nil
+	}
+
+	hostnameEndpoints, err := sc.hostnameEndpointsFunc(ctx, httpProxy, ttl, providerSpecific, setIdentifier, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip endpoints if we do not want entries from annotations
+	var hostnameList []string
+	if !sc.ignoreHostnameAnnotation {
+		hostnameList = getHostnamesFromAnnotations(httpProxy.Annotations)
+	}
+
+	if sc.replaceFQDNWithHostnames && len(hostnameList) > 0 {
+		log.Debugf("Replacing the fqdn of HTTPProxy %s/%s with its hostname annotation", httpProxy.Namespace, httpProxy.Name)
+	} else if virtualHost := httpProxy.Spec.VirtualHost; virtualHost != nil {
+		for _, fqdn := range contourFQDNs(virtualHost.Fqdn, additionalFQDNs, sc.fqdnSuffix) {
+			for _, hostname := range contourVirtualHostnames(fqdn, sc.emitWWWAlias) {
+				endpoints = append(endpoints, hostnameEndpoints(hostname)...)
+			}
+		}
+	}
+
+	for _, hostname := range hostnameList {
+		hostname = strings.TrimSuffix(hostname, ".")
+		endpoints = append(endpoints, hostnameEndpoints(hostname)...)
+	}
+
+	return endpoints, nil
+}
+
+// httpProxyIngressClass returns the ingress class of the HTTPProxy, which is declared by the given
+// spec.ingressClassName in newer versions of Contour or else by either ingress class annotation.
+func httpProxyIngressClass(hp *projectcontour.HTTPProxy, ingressClassName string) string {
+	if ingressClassName != "" {
+		return ingressClassName
+	}
+	if class := hp.Annotations[contourIngressClassAnnotationKey]; class != "" {
+		return class
+	}
+	return hp.Annotations[heptioIngressClassAnnotationKey]
+}
+
+// hostnameEndpointsFunc returns a function generating the endpoints of the hostnames served by the HTTPProxy.
+// They point at the targets of its target annotation, which may carry weights, or else at its load balancer.
+// Without a target annotation, the weights of its routes are published with the targets of their services
+// instead, if the source is configured to.
+func (sc *httpProxySource) hostnameEndpointsFunc(ctx context.Context, httpProxy *projectcontour.HTTPProxy, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, recordType string) (func(hostname string) []*endpoint.Endpoint, error) {
+	if _, ok := httpProxy.Annotations[targetAnnotationKey]; sc.routeWeightsToDNS && !ok {
+		if services, weights := routeServiceWeights(httpProxy); len(services) > 0 {
+			targets, err := sc.targetsFromServices(ctx, httpProxy.Namespace, services)
+			if err != nil {
+				return nil, err
+			}
+			return func(hostname string) []*endpoint.Endpoint {
+				return endpointsForServiceWeights(hostname, services, weights, targets, ttl, providerSpecific, setIdentifier, recordType)
+			}, nil
+		}
+	}
 
+	targets, weights := getWeightedTargetsFromTargetAnnotation(httpProxy.Annotations)
 	if len(targets) == 0 {
 		for _, lb := range httpProxy.Status.LoadBalancer.Ingress {
 			if lb.IP != "" {
@@ -299,26 +514,268 @@ func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTP
 			}
 		}
 	}
+	return func(hostname string) []*endpoint.Endpoint {
+		return endpointsForWeightedHostname(hostname, targets, weights, ttl, providerSpecific, setIdentifier, recordType)
+	}, nil
+}
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
+// routeServiceWeights returns the distinct upstream services of the routes of the HTTPProxy
+// in order, along with their weights. Nothing is returned unless any service has a weight.
+// A service used by multiple routes keeps the weight of its first use.
+func routeServiceWeights(httpProxy *projectcontour.HTTPProxy) ([]string, map[string]int64) {
+	var services []string
+	weights := map[string]int64{}
+	weighted := false
+	for _, route := range httpProxy.Spec.Routes {
+		for _, service := range route.Services {
+			if _, ok := weights[service.Name]; ok {
+				continue
+			}
+			services = append(services, service.Name)
+			weights[service.Name] = int64(service.Weight)
+			weighted = weighted || service.Weight > 0
+		}
+	}
+	if !weighted {
+		return nil, nil
+	}
+	return services, weights
+}
+
+// targetsFromServices returns the load balancer targets of the named services in the namespace.
+// Services which don't exist have no targets, while other lookup errors are returned, so that
+// the records of the services aren't deleted because of a transient error.
+func (sc *httpProxySource) targetsFromServices(ctx context.Context, namespace string, services []string) (map[string]endpoint.Targets, error) {
+	targets := make(map[string]endpoint.Targets, len(services))
+	for _, name := range services {
+		svc, err := getServiceWithRetries(ctx, sc.kubeClient, namespace, name, sc.targetLookupRetries)
+		if apierrors.IsNotFound(err) {
+			log.Warn(err)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get service %s/%s", namespace, name)
+		}
+		for _, lb := range svc.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				targets[name] = append(targets[name], lb.IP)
+			}
+			if lb.Hostname != "" {
+				targets[name] = append(targets[name], lb.Hostname)
+			}
+		}
+	}
+	return targets, nil
+}
 
-	if virtualHost := httpProxy.Spec.VirtualHost; virtualHost != nil {
-		if fqdn := virtualHost.Fqdn; fqdn != "" {
-			endpoints = append(endpoints, endpointsForHostname(fqdn, targets, ttl, providerSpecific, setIdentifier)...)
+// endpointsForServiceWeights returns one weighted endpoint of the hostname for each upstream service.
+// Each endpoint points at the targets of its own service and is identified by the name of the service.
+// Services without targets are skipped.
+func endpointsForServiceWeights(hostname string, services []string, weights map[string]int64, targets map[string]endpoint.Targets, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, recordType string) []*endpoint.Endpoint {
+	var endpoints []*endpoint.Endpoint
+	for _, service := range services {
+		if len(targets[service]) == 0 {
+			log.Debugf("No targets could be found for service %s of %s", service, hostname)
+			continue
 		}
+		weightedProviderSpecific := append(endpoint.ProviderSpecific{}, providerSpecific...)
+		weightedProviderSpecific = append(weightedProviderSpecific, endpoint.ProviderSpecificProperty{
+			Name:  "aws/weight",
+			Value: strconv.FormatInt(weights[service], 10),
+		})
+		serviceSetIdentifier := service
+		if setIdentifier != "" {
+			serviceSetIdentifier = setIdentifier + "-" + service
+		}
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets[service], ttl, weightedProviderSpecific, serviceSetIdentifier, recordType)...)
 	}
+	return endpoints
+}
 
-	// Skip endpoints if we do not want entries from annotations
-	if !sc.ignoreHostnameAnnotation {
-		hostnameList := getHostnamesFromAnnotations(httpProxy.Annotations)
-		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+// getWeightedTargetsFromTargetAnnotation gets endpoints from optional "target" annotation,
+// along with the weights declared for them in the form "target=weight".
+// Targets without a valid weight are returned without an entry in the weights map.
+// Only the HTTPProxy source supports weighted targets.
+func getWeightedTargetsFromTargetAnnotation(annotations map[string]string) (endpoint.Targets, map[string]int64) {
+	var targets endpoint.Targets
+	weights := map[string]int64{}
+	for _, target := range getTargetsFromTargetAnnotation(annotations) {
+		var weight string
+		if i := strings.LastIndex(target, "="); i >= 0 {
+			target, weight = strings.TrimSuffix(target[:i], "."), target[i+1:]
+		}
+		targets = append(targets, target)
+		if weight == "" {
+			continue
 		}
+		w, err := strconv.ParseInt(weight, 10, 64)
+		if err != nil || w < 0 {
+			log.Warnf("Ignoring invalid weight %q of target %q", weight, target)
+			continue
+		}
+		weights[target] = w
 	}
+	return targets, weights
+}
 
+// checkWeightedTargetAnnotation is like checkTargetAnnotation, but ignores the weights of the targets.
+func checkWeightedTargetAnnotation(annotations map[string]string) error {
+	targets, _ := getWeightedTargetsFromTargetAnnotation(annotations)
+	return checkTargets(targets)
+}
+
+// endpointsForWeightedHostname returns the endpoint objects for each host-target combination.
+// Every target with a weight gets an endpoint of its own, carrying the weight as provider
+// specific property and a set identifier distinguishing it from its siblings.
+func endpointsForWeightedHostname(hostname string, targets endpoint.Targets, weights map[string]int64, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, recordType string) []*endpoint.Endpoint {
+	if len(weights) == 0 {
+		return endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)
+	}
+
+	var endpoints []*endpoint.Endpoint
+	var unweighted endpoint.Targets
+	for _, t := range targets {
+		weight, ok := weights[t]
+		if !ok {
+			unweighted = append(unweighted, t)
+			continue
+		}
+		weightedProviderSpecific := append(endpoint.ProviderSpecific{}, providerSpecific...)
+		weightedProviderSpecific = append(weightedProviderSpecific, endpoint.ProviderSpecificProperty{
+			Name:  "aws/weight",
+			Value: strconv.FormatInt(weight, 10),
+		})
+		weightedSetIdentifier := t
+		if setIdentifier != "" {
+			weightedSetIdentifier = setIdentifier + "-" + t
+		}
+		endpoints = append(endpoints, endpointsForHostname(hostname, endpoint.Targets{t}, ttl, weightedProviderSpecific, weightedSetIdentifier, recordType)...)
+	}
+	if len(unweighted) > 0 {
+		endpoints = append(endpoints, endpointsForHostname(hostname, unweighted, ttl, providerSpecific, setIdentifier, recordType)...)
+	}
+	return endpoints
+}
+
+// endpointsFromIncludes returns endpoints for the hostname annotations of the HTTPProxies
+// included by the given root. They share the targets of the root, and hostnames the root
+// already generated endpoints for are skipped.
+func (sc *httpProxySource) endpointsFromIncludes(ctx context.Context, root *projectcontour.HTTPProxy, children []*projectcontour.HTTPProxy, rootEndpoints []*endpoint.Endpoint, validConditions map[string]bool) ([]*endpoint.Endpoint, error) {
+	if sc.ignoreHostnameAnnotation || len(children) == 0 || !sc.isValid(root, validConditions) {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, ep := range rootEndpoints {
+		seen[ep.DNSName] = true
+	}
+
+	ttl, err := getTTLFromAnnotations(root.Annotations)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(root.Annotations)
+	recordType := getRecordTypeFromAnnotations(root.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping the includes of HTTPProxy %s/%s: %v", root.Namespace, root.Name, err)
+		return nil, nil
+	}
+
+	hostnameEndpoints, err := sc.hostnameEndpointsFunc(ctx, root, ttl, providerSpecific, setIdentifier, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, child := range children {
+		if !sc.isValid(child, validConditions) {
+			log.Debugf("Skipping HTTPProxy %s/%s included by %s/%s because it is not valid", child.Namespace, child.Name, root.Namespace, root.Name)
+			continue
+		}
+		for _, hostname := range getHostnamesFromAnnotations(child.Annotations) {
+			hostname = strings.TrimSuffix(hostname, ".")
+			if seen[hostname] {
+				continue
+			}
+			seen[hostname] = true
+			endpoints = append(endpoints, hostnameEndpoints(hostname)...)
+		}
+	}
 	return endpoints, nil
 }
 
+// includedHTTPProxies returns the HTTPProxies transitively included by the given root,
+// looking them up by namespace/name in byName. The namespace of an include defaults to the including HTTPProxy's.
+func includedHTTPProxies(root *projectcontour.HTTPProxy, byName map[string]*projectcontour.HTTPProxy) []*projectcontour.HTTPProxy {
+	var children []*projectcontour.HTTPProxy
+	visited := map[string]bool{root.Namespace + "/" + root.Name: true}
+	queue := []*projectcontour.HTTPProxy{root}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, include := range parent.Spec.Includes {
+			namespace := include.Namespace
+			if namespace == "" {
+				namespace = parent.Namespace
+			}
+			key := namespace + "/" + include.Name
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			child, ok := byName[key]
+			if !ok {
+				log.Debugf("HTTPProxy %s/%s includes unknown HTTPProxy %s", parent.Namespace, parent.Name, key)
+				continue
+			}
+			children = append(children, child)
+			queue = append(queue, child)
+		}
+	}
+	return children
+}
+
+// selectedIncludes returns the included HTTPProxies which are selected, by namespace/name in selected.
+func selectedIncludes(children []*projectcontour.HTTPProxy, selected map[string]bool) []*projectcontour.HTTPProxy {
+	var selectedChildren []*projectcontour.HTTPProxy
+	for _, child := range children {
+		if selected[child.Namespace+"/"+child.Name] {
+			selectedChildren = append(selectedChildren, child)
+		}
+	}
+	return selectedChildren
+}
+
+// isValid returns whether endpoints should be generated for the HTTPProxy given its status.
+// Contour may report validity through status conditions before updating the current status,
+// so HTTPProxies with a Valid condition, by namespace/name in validConditions, are accepted if configured.
+func (sc *httpProxySource) isValid(httpProxy *projectcontour.HTTPProxy, validConditions map[string]bool) bool {
+	if sc.publishInvalid || strings.EqualFold(httpProxy.Status.CurrentStatus, "valid") {
+		return true
+	}
+	return sc.acceptConditions && validConditions[httpProxy.Namespace+"/"+httpProxy.Name]
+}
+
+// hasValidCondition returns whether the status conditions of the unstructured HTTPProxy
+// contain a Valid condition with status True.
+func hasValidCondition(hp *unstructured.Unstructured) bool {
+	conditions, _, err := unstructured.NestedSlice(hp.Object, "status", "conditions")
+	if err != nil {
+		return false
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Valid" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
 func (sc *httpProxySource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for httpproxy")
 
@@ -19,16 +19,22 @@ package source
 import (
 	"context"
 	v1 "k8s.io/api/core/v1"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
 	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
@@ -45,14 +51,10 @@ func (suite *HTTPProxySuite) SetupTest() {
 	fakeDynamicClient, s := newDynamicKubernetesClient()
 	var err error
 
-	suite.source, err = NewContourHTTPProxySource(
-		fakeDynamicClient,
-		"default",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
+	suite.source, err = NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+		Namespace:    "default",
+		FQDNTemplate: "{{.Name}}",
+	})
 	suite.NoError(err, "should initialize httpproxy source")
 
 	suite.httpProxy = (fakeHTTPProxy{
@@ -90,6 +92,7 @@ func TestHTTPProxy(t *testing.T) {
 	suite.Run(t, new(HTTPProxySuite))
 	t.Run("endpointsFromHTTPProxy", testEndpointsFromHTTPProxy)
 	t.Run("Endpoints", testHTTPProxyEndpoints)
+	t.Run("Conditions", testHTTPProxyConditions)
 }
 
 func TestNewContourHTTPProxySource(t *testing.T) {
@@ -134,14 +137,11 @@ func TestNewContourHTTPProxySource(t *testing.T) {
 		t.Run(ti.title, func(t *testing.T) {
 			fakeDynamicClient, _ := newDynamicKubernetesClient()
 
-			_, err := NewContourHTTPProxySource(
-				fakeDynamicClient,
-				"",
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				false,
-			)
+			_, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+				AnnotationFilter:         ti.annotationFilter,
+				FQDNTemplate:             ti.fqdnTemplate,
+				CombineFQDNAndAnnotation: ti.combineFQDNAndAnnotation,
+			})
 			if ti.expectError {
 				assert.Error(t, err)
 			} else {
@@ -187,6 +187,28 @@ func testEndpointsFromHTTPProxy(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "fqdn and hostname annotation with trailing dots",
+			httpProxy: fakeHTTPProxy{
+				host: "foo.bar.",
+				annotations: map[string]string{
+					hostnameAnnotationKey: "baz.bar.",
+				},
+				loadBalancer: fakeLoadBalancerService{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "foo.bar",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+				{
+					DNSName: "baz.bar",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 		{
 			title: "one rule.host two lb.IP and two lb.Hostname",
 			httpProxy: fakeHTTPProxy{
@@ -207,6 +229,27 @@ func testEndpointsFromHTTPProxy(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "one rule.host IPv4 and IPv6 lb.IP",
+			httpProxy: fakeHTTPProxy{
+				host: "foo.bar",
+				loadBalancer: fakeLoadBalancerService{
+					ips: []string{"8.8.8.8", "2001:db8::1"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.bar",
+					Targets:    endpoint.Targets{"8.8.8.8"},
+					RecordType: endpoint.RecordTypeA,
+				},
+				{
+					DNSName:    "foo.bar",
+					Targets:    endpoint.Targets{"2001:db8::1"},
+					RecordType: endpoint.RecordTypeAAAA,
+				},
+			},
+		},
 		{
 			title:     "no rule.host",
 			httpProxy: fakeHTTPProxy{},
@@ -236,7 +279,7 @@ func testEndpointsFromHTTPProxy(t *testing.T) {
 		t.Run(ti.title, func(t *testing.T) {
 			if source, err := newTestHTTPProxySource(); err != nil {
 				require.NoError(t, err)
-			} else if endpoints, err := source.endpointsFromHTTPProxy(ti.httpProxy.HTTPProxy()); err != nil {
+			} else if endpoints, err := source.endpointsFromHTTPProxy(context.Background(), ti.httpProxy.HTTPProxy(), nil, nil); err != nil {
 				require.NoError(t, err)
 			} else {
 				validateEndpoints(t, endpoints, ti.expected)
@@ -251,6 +294,7 @@ func testHTTPProxyEndpoints(t *testing.T) {
 		title                    string
 		targetNamespace          string
 		annotationFilter         string
+		labelFilter              string
 		loadBalancer             fakeLoadBalancerService
 		httpProxyItems           []fakeHTTPProxy
 		expected                 []*endpoint.Endpoint
@@ -258,6 +302,7 @@ func testHTTPProxyEndpoints(t *testing.T) {
 		fqdnTemplate             string
 		combineFQDNAndAnnotation bool
 		ignoreHostnameAnnotation bool
+		publishInvalid           bool
 	}{
 		{
 			title:           "no httpproxy",
@@ -475,6 +520,57 @@ func testHTTPProxyEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{},
 		},
+		{
+			title:           "valid matching label filter",
+			targetNamespace: "",
+			labelFilter:     "team=payments",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					labels: map[string]string{
+						"team": "payments",
+					},
+					host: "example.org",
+				},
+				{
+					name:      "fake2",
+					namespace: namespace,
+					labels: map[string]string{
+						"team": "search",
+					},
+					host: "new.example.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "valid non-matching label filter",
+			targetNamespace: "",
+			labelFilter:     "team=payments",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					annotations: map[string]string{
+						"team": "payments",
+					},
+					host: "example.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
 		{
 			title:           "our controller type is dns-controller",
 			targetNamespace: "",
@@ -787,6 +883,37 @@ func testHTTPProxyEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:           "httpproxy with weighted target annotation",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					annotations: map[string]string{
+						targetAnnotationKey: "1.2.3.4=70,5.6.7.8=30",
+					},
+					host: "example.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:       "example.org",
+					Targets:       endpoint.Targets{"1.2.3.4"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "1.2.3.4",
+				},
+				{
+					DNSName:       "example.org",
+					Targets:       endpoint.Targets{"5.6.7.8"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "5.6.7.8",
+				},
+			},
+		},
 		{
 			title:           "httpproxy rules with annotation and custom TTL",
 			targetNamespace: "",
@@ -840,6 +967,32 @@ func testHTTPProxyEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:           "template for httpproxy with IPv4 and IPv6 load balancer",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8", "2001:db8::1"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "fake1.ext-dns.test.com",
+					Targets:    endpoint.Targets{"8.8.8.8"},
+					RecordType: endpoint.RecordTypeA,
+				},
+				{
+					DNSName:    "fake1.ext-dns.test.com",
+					Targets:    endpoint.Targets{"2001:db8::1"},
+					RecordType: endpoint.RecordTypeAAAA,
+				},
+			},
+			fqdnTemplate: "{{.Name}}.ext-dns.test.com",
+		},
 		{
 			title:           "template for httpproxy with annotation",
 			targetNamespace: "",
@@ -957,125 +1110,1210 @@ func testHTTPProxyEndpoints(t *testing.T) {
 			},
 			ignoreHostnameAnnotation: true,
 		},
-	} {
-		t.Run(ti.title, func(t *testing.T) {
-			httpProxies := make([]*projectcontour.HTTPProxy, 0)
-			for _, item := range ti.httpProxyItems {
-				item.loadBalancer = ti.loadBalancer
-				httpProxies = append(httpProxies, item.HTTPProxy())
-			}
-
-			fakeDynamicClient, scheme := newDynamicKubernetesClient()
-			for _, httpProxy := range httpProxies {
-				converted, err := convertHTTPProxyToUnstructured(httpProxy, scheme)
-				require.NoError(t, err)
-				_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(httpProxy.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
-				require.NoError(t, err)
-			}
-
-			httpProxySource, err := NewContourHTTPProxySource(
-				fakeDynamicClient,
-				ti.targetNamespace,
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				ti.ignoreHostnameAnnotation,
-			)
-			require.NoError(t, err)
-
-			res, err := httpProxySource.Endpoints(context.Background())
-			if ti.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-
-			validateEndpoints(t, res, ti.expected)
-		})
-	}
-}
-
-// httpproxy specific helper functions
-func newTestHTTPProxySource() (*httpProxySource, error) {
-	fakeDynamicClient, _ := newDynamicKubernetesClient()
-
-	src, err := NewContourHTTPProxySource(
-		fakeDynamicClient,
-		"default",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	irsrc, ok := src.(*httpProxySource)
-	if !ok {
-		return nil, errors.New("underlying source type was not httpproxy")
-	}
-
-	return irsrc, nil
-}
-
-type fakeHTTPProxy struct {
-	namespace   string
-	name        string
-	annotations map[string]string
-
-	host         string
-	invalid      bool
-	delegate     bool
-	loadBalancer fakeLoadBalancerService
-}
-
-func (ir fakeHTTPProxy) HTTPProxy() *projectcontour.HTTPProxy {
-	var status string
-	if ir.invalid {
-		status = "invalid"
-	} else {
-		status = "valid"
-	}
-
-	var spec projectcontour.HTTPProxySpec
-	if ir.delegate {
-		spec = projectcontour.HTTPProxySpec{}
-	} else {
-		spec = projectcontour.HTTPProxySpec{
-			VirtualHost: &projectcontour.VirtualHost{
-				Fqdn: ir.host,
+		{
+			title:           "httpproxy with uppercase valid status",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					status:    "Valid",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
 			},
-		}
-	}
-
-	lb := v1.LoadBalancerStatus{
-		Ingress: []v1.LoadBalancerIngress{},
-	}
-
-	for _, ip := range ir.loadBalancer.ips {
-		lb.Ingress = append(lb.Ingress, v1.LoadBalancerIngress{
-			IP: ip,
-		})
-	}
-	for _, hostname := range ir.loadBalancer.hostnames {
-		lb.Ingress = append(lb.Ingress, v1.LoadBalancerIngress{
-			Hostname: hostname,
-		})
-	}
-
-	httpProxy := &projectcontour.HTTPProxy{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:   ir.namespace,
-			Name:        ir.name,
-			Annotations: ir.annotations,
 		},
-		Spec: spec,
-		Status: projectcontour.Status{
-			CurrentStatus: status,
-			LoadBalancer:  lb,
+		{
+			title:           "invalid httpproxy",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					invalid:   true,
+				},
+			},
+			expected: []*endpoint.Endpoint{},
 		},
-	}
-
-	return httpProxy
+		{
+			title:           "invalid httpproxy with publish invalid",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					invalid:   true,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+			publishInvalid: true,
+		},
+		{
+			title:           "root httpproxy aggregates hostnames of included httpproxies",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					includes: []projectcontour.Include{
+						{Name: "child1"},
+						{Name: "child2", Namespace: "other"},
+					},
+				},
+				{
+					name:      "child1",
+					namespace: namespace,
+					delegate:  true,
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app.example.org,example.org",
+					},
+				},
+				{
+					name:      "child2",
+					namespace: "other",
+					delegate:  true,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+				{
+					DNSName: "app.example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title:           "included httpproxy hostname with trailing dot equal to the root fqdn",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org.",
+					includes: []projectcontour.Include{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+					annotations: map[string]string{
+						hostnameAnnotationKey: "example.org.",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title:           "included httpproxy hostnames are ignored with ignore hostname annotations",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					includes: []projectcontour.Include{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app.example.org",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+			ignoreHostnameAnnotation: true,
+		},
+		{
+			title:           "root httpproxy selected by the label filter includes unlabeled httpproxies",
+			targetNamespace: "",
+			labelFilter:     "app=foo",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					labels:    map[string]string{"app": "foo"},
+					includes: []projectcontour.Include{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app.example.org",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+				{
+					DNSName: "app.example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title:           "included httpproxy selected by the label filter is published through an unlabeled root",
+			targetNamespace: "",
+			labelFilter:     "app=foo",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					includes: []projectcontour.Include{
+						{Name: "child1"},
+						{Name: "child2"},
+					},
+				},
+				{
+					name:      "child1",
+					namespace: namespace,
+					delegate:  true,
+					labels:    map[string]string{"app": "foo"},
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app1.example.org",
+					},
+				},
+				{
+					name:      "child2",
+					namespace: namespace,
+					delegate:  true,
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app2.example.org",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "app1.example.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+		},
+		{
+			title:           "included httpproxy selected by the label filter is not published through a root of another controller",
+			targetNamespace: "",
+			labelFilter:     "app=foo",
+			loadBalancer: fakeLoadBalancerService{
+				hostnames: []string{"lb.com"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					annotations: map[string]string{
+						controllerAnnotationKey: "other",
+					},
+					includes: []projectcontour.Include{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+					labels:    map[string]string{"app": "foo"},
+					annotations: map[string]string{
+						hostnameAnnotationKey: "app.example.org",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			httpProxies := make([]*projectcontour.HTTPProxy, 0)
+			for _, item := range ti.httpProxyItems {
+				item.loadBalancer = ti.loadBalancer
+				httpProxies = append(httpProxies, item.HTTPProxy())
+			}
+
+			fakeDynamicClient, scheme := newDynamicKubernetesClient()
+			for _, httpProxy := range httpProxies {
+				converted, err := convertHTTPProxyToUnstructured(httpProxy, scheme)
+				require.NoError(t, err)
+				_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(httpProxy.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			httpProxySource, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+				Namespace:                ti.targetNamespace,
+				AnnotationFilter:         ti.annotationFilter,
+				LabelFilter:              ti.labelFilter,
+				FQDNTemplate:             ti.fqdnTemplate,
+				CombineFQDNAndAnnotation: ti.combineFQDNAndAnnotation,
+				IgnoreHostnameAnnotation: ti.ignoreHostnameAnnotation,
+				ContourPublishInvalid:    ti.publishInvalid,
+			})
+			require.NoError(t, err)
+
+			res, err := httpProxySource.Endpoints(context.Background())
+			if ti.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			validateEndpoints(t, res, ti.expected)
+		})
+	}
+}
+
+func testHTTPProxyConditions(t *testing.T) {
+	validCondition := map[string]interface{}{"type": "Valid", "status": "True"}
+	invalidCondition := map[string]interface{}{"type": "Valid", "status": "False"}
+	for _, ti := range []struct {
+		title            string
+		invalid          bool
+		conditions       []interface{}
+		acceptConditions bool
+		expected         []*endpoint.Endpoint
+	}{
+		{
+			title:      "valid status without accepting conditions",
+			conditions: []interface{}{invalidCondition},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}},
+			},
+		},
+		{
+			title:      "invalid status with valid condition without accepting conditions",
+			invalid:    true,
+			conditions: []interface{}{validCondition},
+			expected:   []*endpoint.Endpoint{},
+		},
+		{
+			title:            "invalid status with valid condition accepting conditions",
+			invalid:          true,
+			conditions:       []interface{}{validCondition},
+			acceptConditions: true,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}},
+			},
+		},
+		{
+			title:            "invalid status with invalid condition accepting conditions",
+			invalid:          true,
+			conditions:       []interface{}{invalidCondition},
+			acceptConditions: true,
+			expected:         []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			httpProxy := fakeHTTPProxy{
+				name:      "fake1",
+				namespace: "testing",
+				host:      "example.org",
+				invalid:   ti.invalid,
+				loadBalancer: fakeLoadBalancerService{
+					ips: []string{"8.8.8.8"},
+				},
+			}.HTTPProxy()
+
+			fakeDynamicClient, scheme := newDynamicKubernetesClient()
+			converted, err := convertHTTPProxyToUnstructured(httpProxy, scheme)
+			require.NoError(t, err)
+			require.NoError(t, unstructured.SetNestedSlice(converted.Object, ti.conditions, "status", "conditions"))
+			_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(httpProxy.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			httpProxySource, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{ContourAcceptConditions: ti.acceptConditions})
+			require.NoError(t, err)
+
+			res, err := httpProxySource.Endpoints(context.Background())
+			require.NoError(t, err)
+
+			validateEndpoints(t, res, ti.expected)
+		})
+	}
+}
+
+// httpproxy specific helper functions
+// TestHTTPProxyLogFields tests that skipped HTTPProxies are logged with structured fields identifying them.
+func TestHTTPProxyIsValid(t *testing.T) {
+	valid := fakeHTTPProxy{namespace: "default", name: "valid"}.HTTPProxy()
+	invalid := fakeHTTPProxy{namespace: "default", name: "invalid", invalid: true}.HTTPProxy()
+	validConditions := map[string]bool{"default/invalid": true}
+
+	for _, ti := range []struct {
+		title           string
+		source          *httpProxySource
+		httpProxy       *projectcontour.HTTPProxy
+		validConditions map[string]bool
+		expected        bool
+	}{
+		{"valid status", &httpProxySource{}, valid, nil, true},
+		{"invalid status", &httpProxySource{}, invalid, nil, false},
+		{"invalid status with valid condition", &httpProxySource{}, invalid, validConditions, false},
+		{"invalid status with valid condition accepting conditions", &httpProxySource{acceptConditions: true}, invalid, validConditions, true},
+		{"invalid status without valid condition accepting conditions", &httpProxySource{acceptConditions: true}, invalid, nil, false},
+		{"invalid status publishing invalid", &httpProxySource{publishInvalid: true}, invalid, nil, true},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			assert.Equal(t, ti.expected, ti.source.isValid(ti.httpProxy, ti.validConditions))
+		})
+	}
+}
+
+func TestHTTPProxyLogFields(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	level := log.GetLevel()
+	log.SetLevel(log.DebugLevel)
+	defer log.SetLevel(level)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:   "default",
+		name:        "fake",
+		host:        "example.org",
+		annotations: map[string]string{controllerAnnotationKey: "other-controller"},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+
+	var skipped *log.Entry
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "Skipping HTTPProxy because controller value does not match") {
+			skipped = entry
+		}
+	}
+	require.NotNil(t, skipped, "should log the skipped HTTPProxy")
+	assert.Equal(t, log.Fields{"source": "httpproxy", "namespace": "default", "name": "fake"}, skipped.Data)
+}
+
+func TestHTTPProxyDualstackLabel(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "dualstack",
+		host:         "example.org",
+		annotations:  map[string]string{ALBDualstackAnnotationKey: ALBDualstackAnnotationValue},
+		loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "true", endpoints[0].Labels[endpoint.DualstackLabelKey])
+}
+
+func TestHTTPProxyOwnerIDLabel(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "owned",
+		host:         "example.org",
+		annotations:  map[string]string{ownerIDAnnotationKey: "team-a"},
+		loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "team-a", endpoints[0].Labels[endpoint.OwnerIDLabelKey])
+}
+
+func TestHTTPProxyHostnameAnnotationMatchingFQDN(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "same",
+		host:         "example.org",
+		annotations:  map[string]string{hostnameAnnotationKey: "example.org."},
+		loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func TestHTTPProxyMultipleFQDNs(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "multi",
+		host:         "foo.example.org",
+		loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedStringSlice(converted.Object, []string{"bar.example.org", "foo.example.org."}, "spec", "virtualhost", "fqdns"))
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func TestHTTPProxyIngressClass(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	for _, ti := range []struct {
+		name             string
+		annotations      map[string]string
+		ingressClassName string
+	}{
+		{name: "contour-annotation", annotations: map[string]string{contourIngressClassAnnotationKey: "contour"}},
+		{name: "heptio-annotation", annotations: map[string]string{heptioIngressClassAnnotationKey: "contour"}},
+		{name: "class-name", ingressClassName: "contour"},
+		{name: "other-contour-annotation", annotations: map[string]string{contourIngressClassAnnotationKey: "other"}},
+		{name: "other-heptio-annotation", annotations: map[string]string{heptioIngressClassAnnotationKey: "other"}},
+		{name: "other-class-name", annotations: map[string]string{contourIngressClassAnnotationKey: "contour"}, ingressClassName: "other"},
+		{name: "unclassified"},
+	} {
+		hp := fakeHTTPProxy{
+			namespace:    "default",
+			name:         ti.name,
+			host:         ti.name + ".example.org",
+			annotations:  ti.annotations,
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy()
+		converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+		require.NoError(t, err)
+		if ti.ingressClassName != "" {
+			require.NoError(t, unstructured.SetNestedField(converted.Object, ti.ingressClassName, "spec", "ingressClassName"))
+		}
+		_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{ContourIngressClass: "contour"})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "class-name.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "contour-annotation.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "heptio-annotation.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "unclassified.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func TestHTTPProxyWithoutAnnotations(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "bare",
+		host:         "example.org",
+		loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, ti := range []struct {
+		annotationFilter string
+		expected         []*endpoint.Endpoint
+	}{
+		{
+			annotationFilter: "",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			annotationFilter: "kubernetes.io/ingress.class in (contour)",
+			expected:         []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(ti.annotationFilter, func(t *testing.T) {
+			src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{AnnotationFilter: ti.annotationFilter})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+func TestHTTPProxyEndpointsCancelled(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	hp := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "fake",
+		host:         "example.org",
+		loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+	}.HTTPProxy()
+	converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = src.Endpoints(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestHTTPProxyEndpointsWithWarnings(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	for _, hp := range []*projectcontour.HTTPProxy{
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "published",
+			host:         "published.example.org",
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace: "default",
+			name:      "no-targets",
+			host:      "no-targets.example.org",
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "foreign",
+			host:         "foreign.example.org",
+			annotations:  map[string]string{controllerAnnotationKey: "other-controller"},
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "invalid",
+			host:         "invalid.example.org",
+			invalid:      true,
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy(),
+	} {
+		converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+		require.NoError(t, err)
+		_, err = fakeDynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{Namespace: "default"})
+	require.NoError(t, err)
+
+	endpoints, warnings, err := EndpointsWithWarnings(context.Background(), src)
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:    "published.example.org",
+			Targets:    endpoint.Targets{"8.8.8.8"},
+			RecordType: endpoint.RecordTypeA,
+		},
+	})
+
+	reasons := map[string]string{}
+	for _, warning := range warnings {
+		assert.NotEmpty(t, warning.Message)
+		reasons[warning.Resource] = warning.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"HTTPProxy/default/no-targets": skipReasonNoEndpoints,
+		"HTTPProxy/default/foreign":    skipReasonController,
+		"HTTPProxy/default/invalid":    skipReasonInvalid,
+	}, reasons)
+}
+
+func TestHTTPProxyFQDNSuffix(t *testing.T) {
+	src := &httpProxySource{fqdnSuffix: "example.com"}
+
+	for _, ti := range []struct {
+		host     string
+		expected string
+	}{
+		{host: "app", expected: "app.example.com"},
+		{host: "app.example.org", expected: "app.example.org"},
+	} {
+		httpProxy := fakeHTTPProxy{
+			namespace:    "default",
+			name:         "fake",
+			host:         ti.host,
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy()
+
+		endpoints, err := src.endpointsFromHTTPProxy(context.Background(), httpProxy, nil, nil)
+		require.NoError(t, err)
+		validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+			{DNSName: ti.expected, Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		})
+	}
+}
+
+func TestHTTPProxyWWWAlias(t *testing.T) {
+	src := &httpProxySource{emitWWWAlias: true}
+
+	for _, ti := range []struct {
+		host     string
+		expected []string
+	}{
+		{host: "example.com", expected: []string{"example.com", "www.example.com"}},
+		{host: "www.example.com", expected: []string{"www.example.com"}},
+		{host: "app.example.com", expected: []string{"app.example.com"}},
+	} {
+		t.Run(ti.host, func(t *testing.T) {
+			httpProxy := fakeHTTPProxy{
+				namespace:    "default",
+				name:         "fake",
+				host:         ti.host,
+				loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+			}.HTTPProxy()
+
+			endpoints, err := src.endpointsFromHTTPProxy(context.Background(), httpProxy, nil, nil)
+			require.NoError(t, err)
+			var expected []*endpoint.Endpoint
+			for _, name := range ti.expected {
+				expected = append(expected, &endpoint.Endpoint{DNSName: name, Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA})
+			}
+			validateEndpoints(t, endpoints, expected)
+		})
+	}
+}
+
+func TestHTTPProxyHostnameAnnotationStrategy(t *testing.T) {
+	httpProxy := fakeHTTPProxy{
+		namespace:    "default",
+		name:         "renamed",
+		host:         "old.example.org",
+		annotations:  map[string]string{hostnameAnnotationKey: "new.example.org"},
+		loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+	}.HTTPProxy()
+
+	for _, ti := range []struct {
+		strategy string
+		expected []*endpoint.Endpoint
+	}{
+		{
+			strategy: HostnameAnnotationStrategyAppend,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "old.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "new.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			strategy: HostnameAnnotationStrategyReplace,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "new.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+	} {
+		t.Run(ti.strategy, func(t *testing.T) {
+			replace, err := parseHostnameAnnotationStrategy(ti.strategy)
+			require.NoError(t, err)
+			src := &httpProxySource{replaceFQDNWithHostnames: replace}
+
+			endpoints, err := src.endpointsFromHTTPProxy(context.Background(), httpProxy, nil, nil)
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+
+	_, err := parseHostnameAnnotationStrategy("prepend")
+	assert.Error(t, err)
+}
+
+func TestHTTPProxyRouteWeights(t *testing.T) {
+	canary := fakeHTTPProxy{
+		namespace: "default",
+		name:      "canary",
+		host:      "example.org",
+		routes: []projectcontour.Route{
+			{
+				Services: []projectcontour.Service{
+					{Name: "app-stable", Port: 80, Weight: 90},
+					{Name: "app-canary", Port: 80, Weight: 10},
+				},
+			},
+			{
+				Services: []projectcontour.Service{
+					{Name: "app-stable", Port: 80, Weight: 100},
+				},
+			},
+		},
+		loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+	}.HTTPProxy()
+
+	kubeClient := fakeKube.NewSimpleClientset()
+	for name, hostname := range map[string]string{"app-stable": "stable.lb.com", "app-canary": "canary.lb.com"} {
+		_, err := kubeClient.CoreV1().Services("default").Create(context.Background(), &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{Hostname: hostname}}},
+			},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	for _, ti := range []struct {
+		title             string
+		routeWeightsToDNS bool
+		httpProxy         *projectcontour.HTTPProxy
+		expected          []*endpoint.Endpoint
+	}{
+		{
+			title:             "weighted services",
+			routeWeightsToDNS: true,
+			httpProxy:         canary,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"stable.lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					SetIdentifier:    "app-stable",
+					ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/weight", Value: "90"}},
+				},
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"canary.lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					SetIdentifier:    "app-canary",
+					ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/weight", Value: "10"}},
+				},
+			},
+		},
+		{
+			title:             "weighted services without targets",
+			routeWeightsToDNS: true,
+			httpProxy: fakeHTTPProxy{
+				namespace: "default",
+				name:      "missing",
+				host:      "example.org",
+				routes: []projectcontour.Route{
+					{
+						Services: []projectcontour.Service{
+							{Name: "app-stable", Port: 80, Weight: 90},
+							{Name: "app-missing", Port: 80, Weight: 10},
+						},
+					},
+				},
+				loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+			}.HTTPProxy(),
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"stable.lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					SetIdentifier:    "app-stable",
+					ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/weight", Value: "90"}},
+				},
+			},
+		},
+		{
+			title:     "disabled",
+			httpProxy: canary,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					ProviderSpecific: endpoint.ProviderSpecific{},
+				},
+			},
+		},
+		{
+			title:             "target annotation",
+			routeWeightsToDNS: true,
+			httpProxy: fakeHTTPProxy{
+				namespace:   "default",
+				name:        "target",
+				annotations: map[string]string{targetAnnotationKey: "target.com"},
+				host:        "example.org",
+				routes:      canary.Spec.Routes,
+			}.HTTPProxy(),
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"target.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					ProviderSpecific: endpoint.ProviderSpecific{},
+				},
+			},
+		},
+		{
+			title:             "unweighted services",
+			routeWeightsToDNS: true,
+			httpProxy: fakeHTTPProxy{
+				namespace: "default",
+				name:      "unweighted",
+				host:      "example.org",
+				routes: []projectcontour.Route{
+					{
+						Services: []projectcontour.Service{
+							{Name: "app-a", Port: 80},
+							{Name: "app-b", Port: 80},
+						},
+					},
+				},
+				loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+			}.HTTPProxy(),
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "example.org",
+					Targets:          endpoint.Targets{"lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					ProviderSpecific: endpoint.ProviderSpecific{},
+				},
+			},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			src := &httpProxySource{kubeClient: kubeClient, routeWeightsToDNS: ti.routeWeightsToDNS}
+
+			endpoints, err := src.endpointsFromHTTPProxy(context.Background(), ti.httpProxy, nil, nil)
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+			for i, ep := range endpoints {
+				assert.Equal(t, ti.expected[i].SetIdentifier, ep.SetIdentifier)
+				assert.Equal(t, ti.expected[i].ProviderSpecific, ep.ProviderSpecific)
+			}
+		})
+	}
+
+	// The hostnames of the FQDN template are published with the same weights.
+	tmpl, err := parseTemplate("{{.Name}}.example.com")
+	require.NoError(t, err)
+	src := &httpProxySource{kubeClient: kubeClient, routeWeightsToDNS: true, fqdnTemplate: tmpl}
+	endpoints, err := src.endpointsFromTemplate(context.Background(), canary)
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "canary.example.com", Targets: endpoint.Targets{"stable.lb.com"}, RecordType: endpoint.RecordTypeCNAME, SetIdentifier: "app-stable"},
+		{DNSName: "canary.example.com", Targets: endpoint.Targets{"canary.lb.com"}, RecordType: endpoint.RecordTypeCNAME, SetIdentifier: "app-canary"},
+	})
+}
+
+// TestHTTPProxyRouteWeightsLookupError tests that a failed lookup of the services of
+// the weighted routes of an HTTPProxy is returned rather than dropping their endpoints.
+func TestHTTPProxyRouteWeightsLookupError(t *testing.T) {
+	kubeClient := fakeKube.NewSimpleClientset()
+	kubeClient.PrependReactor("get", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewServiceUnavailable("unavailable")
+	})
+	src := &httpProxySource{kubeClient: kubeClient, routeWeightsToDNS: true}
+
+	httpProxy := fakeHTTPProxy{
+		namespace: "default",
+		name:      "canary",
+		host:      "example.org",
+		routes: []projectcontour.Route{
+			{
+				Services: []projectcontour.Service{
+					{Name: "app-stable", Port: 80, Weight: 90},
+					{Name: "app-canary", Port: 80, Weight: 10},
+				},
+			},
+		},
+		loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.com"}},
+	}.HTTPProxy()
+
+	_, err := src.endpointsFromHTTPProxy(context.Background(), httpProxy, nil, nil)
+	assert.Error(t, err)
+}
+
+// TestHTTPProxyRoutingPolicyWithoutSetIdentifier tests that an HTTPProxy with a routing policy
+// annotation but without set identifier is skipped.
+func TestHTTPProxyRoutingPolicyWithoutSetIdentifier(t *testing.T) {
+	src, err := newTestHTTPProxySource()
+	require.NoError(t, err)
+
+	for _, ti := range []struct {
+		title       string
+		annotations map[string]string
+		expected    int
+	}{
+		{
+			title:       "weight without set identifier",
+			annotations: map[string]string{"external-dns.alpha.kubernetes.io/aws-weight": "10"},
+		},
+		{
+			title:       "geolocation without set identifier",
+			annotations: map[string]string{"external-dns.alpha.kubernetes.io/aws-geolocation-country-code": "DE"},
+		},
+		{
+			title: "weight with set identifier",
+			annotations: map[string]string{
+				"external-dns.alpha.kubernetes.io/aws-weight": "10",
+				SetIdentifierKey: "blue",
+			},
+			expected: 1,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			httpProxy := fakeHTTPProxy{
+				namespace:    "default",
+				name:         "weighted",
+				host:         "example.org",
+				annotations:  ti.annotations,
+				loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+			}.HTTPProxy()
+
+			endpoints, err := src.endpointsFromHTTPProxy(context.Background(), httpProxy, nil, nil)
+			require.NoError(t, err)
+			assert.Len(t, endpoints, ti.expected)
+
+			endpoints, err = src.endpointsFromTemplate(context.Background(), httpProxy)
+			require.NoError(t, err)
+			assert.Len(t, endpoints, ti.expected)
+		})
+	}
+}
+
+func newTestHTTPProxySource() (*httpProxySource, error) {
+	fakeDynamicClient, _ := newDynamicKubernetesClient()
+
+	src, err := NewContourHTTPProxySource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+		Namespace:    "default",
+		FQDNTemplate: "{{.Name}}",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	irsrc, ok := src.(*httpProxySource)
+	if !ok {
+		return nil, errors.New("underlying source type was not httpproxy")
+	}
+
+	return irsrc, nil
+}
+
+type fakeHTTPProxy struct {
+	namespace   string
+	name        string
+	annotations map[string]string
+	labels      map[string]string
+
+	host         string
+	status       string
+	invalid      bool
+	delegate     bool
+	includes     []projectcontour.Include
+	routes       []projectcontour.Route
+	loadBalancer fakeLoadBalancerService
+}
+
+func (ir fakeHTTPProxy) HTTPProxy() *projectcontour.HTTPProxy {
+	status := ir.status
+	if status == "" && ir.invalid {
+		status = "invalid"
+	} else if status == "" {
+		status = "valid"
+	}
+
+	var spec projectcontour.HTTPProxySpec
+	if ir.delegate {
+		spec = projectcontour.HTTPProxySpec{}
+	} else {
+		spec = projectcontour.HTTPProxySpec{
+			VirtualHost: &projectcontour.VirtualHost{
+				Fqdn: ir.host,
+			},
+		}
+	}
+	spec.Includes = ir.includes
+	spec.Routes = ir.routes
+
+	lb := v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{},
+	}
+
+	for _, ip := range ir.loadBalancer.ips {
+		lb.Ingress = append(lb.Ingress, v1.LoadBalancerIngress{
+			IP: ip,
+		})
+	}
+	for _, hostname := range ir.loadBalancer.hostnames {
+		lb.Ingress = append(lb.Ingress, v1.LoadBalancerIngress{
+			Hostname: hostname,
+		})
+	}
+
+	httpProxy := &projectcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   ir.namespace,
+			Name:        ir.name,
+			Annotations: ir.annotations,
+			Labels:      ir.labels,
+		},
+		Spec: spec,
+		Status: projectcontour.Status{
+			CurrentStatus: status,
+			LoadBalancer:  lb,
+		},
+	}
+
+	return httpProxy
+}
+
+func TestGetWeightedTargetsFromTargetAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		title           string
+		annotations     map[string]string
+		expectedTargets endpoint.Targets
+		expectedWeights map[string]int64
+	}{
+		{
+			title:           "plain targets",
+			annotations:     map[string]string{targetAnnotationKey: "1.2.3.4,lb.example.org."},
+			expectedTargets: endpoint.Targets{"1.2.3.4", "lb.example.org"},
+			expectedWeights: map[string]int64{},
+		},
+		{
+			title:           "weighted targets",
+			annotations:     map[string]string{targetAnnotationKey: "1.2.3.4=70, 5.6.7.8=30"},
+			expectedTargets: endpoint.Targets{"1.2.3.4", "5.6.7.8"},
+			expectedWeights: map[string]int64{"1.2.3.4": 70, "5.6.7.8": 30},
+		},
+		{
+			title:           "invalid weight is ignored",
+			annotations:     map[string]string{targetAnnotationKey: "1.2.3.4=foo,lb.example.org.=10"},
+			expectedTargets: endpoint.Targets{"1.2.3.4", "lb.example.org"},
+			expectedWeights: map[string]int64{"lb.example.org": 10},
+		},
+		{
+			title:           "empty targets are ignored",
+			annotations:     map[string]string{targetAnnotationKey: " 1.2.3.4 , ,5.6.7.8,"},
+			expectedTargets: endpoint.Targets{"1.2.3.4", "5.6.7.8"},
+			expectedWeights: map[string]int64{},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			targets, weights := getWeightedTargetsFromTargetAnnotation(tc.annotations)
+			assert.Equal(t, tc.expectedTargets, targets)
+			assert.Equal(t, tc.expectedWeights, weights)
+		})
+	}
+}
+
+func TestEndpointsForWeightedHostname(t *testing.T) {
+	providerSpecific := endpoint.ProviderSpecific{{Name: "alias", Value: "true"}}
+	endpoints := endpointsForWeightedHostname(
+		"example.org",
+		endpoint.Targets{"1.2.3.4", "5.6.7.8", "lb.example.org"},
+		map[string]int64{"1.2.3.4": 70, "5.6.7.8": 30},
+		endpoint.TTL(60),
+		providerSpecific,
+		"blue",
+		"",
+	)
+
+	assert.Len(t, endpoints, 3)
+	for i, expected := range []struct {
+		target        string
+		weight        string
+		setIdentifier string
+	}{
+		{"1.2.3.4", "70", "blue-1.2.3.4"},
+		{"5.6.7.8", "30", "blue-5.6.7.8"},
+		{"lb.example.org", "", "blue"},
+	} {
+		ep := endpoints[i]
+		assert.Equal(t, "example.org", ep.DNSName)
+		assert.Equal(t, endpoint.Targets{expected.target}, ep.Targets)
+		assert.Equal(t, endpoint.TTL(60), ep.RecordTTL)
+		assert.Equal(t, expected.setIdentifier, ep.SetIdentifier)
+		weight, ok := ep.GetProviderSpecificProperty("aws/weight")
+		assert.Equal(t, expected.weight != "", ok)
+		assert.Equal(t, expected.weight, weight.Value)
+	}
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: "alias", Value: "true"}}, providerSpecific, "should not modify the given provider specific properties")
 }
@@ -28,7 +28,6 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	extinformers "k8s.io/client-go/informers/extensions/v1beta1"
 	"k8s.io/client-go/kubernetes"
@@ -57,21 +56,20 @@ type ingressSource struct {
 	ignoreHostnameAnnotation bool
 	ingressInformer          extinformers.IngressInformer
 	ignoreIngressTLSSpec     bool
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewIngressSource creates a new ingressSource with the given config.
-func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool) (Source, error) {
+func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, cacheSyncTimeout time.Duration) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informer to listen for add/update/delete of ingresses in the specified namespace.
@@ -87,18 +85,20 @@ func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return ingressInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("ingress", "Ingress", cacheSyncTimeout, ingressInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	sc := &ingressSource{
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("ingress", ingressInformer.Informer().HasSynced),
 		client:                   kubeClient,
 		namespace:                namespace,
 		annotationFilter:         annotationFilter,
@@ -114,10 +114,19 @@ func NewIngressSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all ingress resources on all namespaces
 func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("ingress")
+
 	ingresses, err := sc.ingressInformer.Lister().Ingresses(sc.namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
+	for i, ing := range ingresses {
+		if annotations, ok := sc.translateAnnotations(ing.Annotations); ok {
+			ing = ing.DeepCopy()
+			ing.Annotations = annotations
+			ingresses[i] = ing
+		}
+	}
 	ingresses, err = sc.filterByAnnotations(ingresses)
 	if err != nil {
 		return nil, err
@@ -131,6 +140,12 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping ingress %s/%s because controller value does not match, found: %s, required: %s",
 				ing.Namespace, ing.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(ing.Annotations); err != nil {
+			log.Warnf("Skipping ingress %s/%s: %v", ing.Namespace, ing.Name, err)
+			metrics.skip(skipReasonInvalid)
 			continue
 		}
 
@@ -152,12 +167,14 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		if len(ingEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from ingress %s/%s", ing.Namespace, ing.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from ingress: %s/%s: %v", ing.Namespace, ing.Name, ingEndpoints)
 		sc.setResourceLabel(ing, ingEndpoints)
-		sc.setDualstackLabel(ing, ingEndpoints)
+		setDualstackLabel(ing.Annotations, fmt.Sprintf("ingress %s/%s", ing.Namespace, ing.Name), ingEndpoints)
+		setOwnerIDLabel(ing.Annotations, ingEndpoints)
 		endpoints = append(endpoints, ingEndpoints...)
 	}
 
@@ -165,6 +182,7 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
@@ -190,13 +208,14 @@ func (sc *ingressSource) endpointsFromTemplate(ing *v1beta1.Ingress) ([]*endpoin
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ing.Annotations)
+	recordType := getRecordTypeFromAnnotations(ing.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	// splits the FQDN template and removes the trailing periods
 	hostnameList := strings.Split(strings.Replace(hostnames, " ", "", -1), ",")
 	for _, hostname := range hostnameList {
 		hostname = strings.TrimSuffix(hostname, ".")
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 	return endpoints, nil
 }
@@ -231,16 +250,6 @@ func (sc *ingressSource) setResourceLabel(ingress *v1beta1.Ingress, endpoints []
 	}
 }
 
-func (sc *ingressSource) setDualstackLabel(ingress *v1beta1.Ingress, endpoints []*endpoint.Endpoint) {
-	val, ok := ingress.Annotations[ALBDualstackAnnotationKey]
-	if ok && val == ALBDualstackAnnotationValue {
-		log.Debugf("Adding dualstack label to ingress %s/%s.", ingress.Namespace, ingress.Name)
-		for _, ep := range endpoints {
-			ep.Labels[endpoint.DualstackLabelKey] = "true"
-		}
-	}
-}
-
 // endpointsFromIngress extracts the endpoints from ingress object
 func endpointsFromIngress(ing *v1beta1.Ingress, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
@@ -257,12 +266,13 @@ func endpointsFromIngress(ing *v1beta1.Ingress, ignoreHostnameAnnotation bool, i
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ing.Annotations)
+	recordType := getRecordTypeFromAnnotations(ing.Annotations)
 
 	for _, rule := range ing.Spec.Rules {
 		if rule.Host == "" {
 			continue
 		}
-		endpoints = append(endpoints, endpointsForHostname(rule.Host, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(rule.Host, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	// Skip endpoints if we do not want entries from tls spec section
@@ -272,7 +282,7 @@ func endpointsFromIngress(ing *v1beta1.Ingress, ignoreHostnameAnnotation bool, i
 				if host == "" {
 					continue
 				}
-				endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier)...)
+				endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 			}
 		}
 	}
@@ -281,7 +291,7 @@ func endpointsFromIngress(ing *v1beta1.Ingress, ignoreHostnameAnnotation bool, i
 	if !ignoreHostnameAnnotation {
 		hostnameList := getHostnamesFromAnnotations(ing.Annotations)
 		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 		}
 	}
 	return endpoints
@@ -53,6 +53,7 @@ func (suite *IngressSuite) SetupTest() {
 		false,
 		false,
 		false,
+		0,
 	)
 	suite.NoError(err, "should initialize ingress source")
 
@@ -136,6 +137,7 @@ func TestNewIngressSource(t *testing.T) {
 				ti.combineFQDNAndAnnotation,
 				false,
 				false,
+				0,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -302,6 +304,55 @@ func testIngressEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:           "ingress with multiple targets in the target annotation",
+			targetNamespace: "",
+			ingressItems: []fakeIngress{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					annotations: map[string]string{
+						targetAnnotationKey: "1.2.3.4, 5.6.7.8",
+					},
+					dnsnames: []string{"example.org"},
+					ips:      []string{"8.8.8.8"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					Targets:    endpoint.Targets{"1.2.3.4", "5.6.7.8"},
+					RecordType: endpoint.RecordTypeA,
+				},
+			},
+		},
+		{
+			title:           "ingress with IPs and hostnames mixed in the target annotation is skipped",
+			targetNamespace: "",
+			ingressItems: []fakeIngress{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					annotations: map[string]string{
+						targetAnnotationKey: "1.2.3.4,lb.example.org",
+					},
+					dnsnames: []string{"example.org"},
+					ips:      []string{"8.8.8.8"},
+				},
+				{
+					name:      "fake2",
+					namespace: namespace,
+					dnsnames:  []string{"new.org"},
+					hostnames: []string{"lb.com"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "new.org",
+					Targets: endpoint.Targets{"lb.com"},
+				},
+			},
+		},
 		{
 			title:           "two simple ingresses on different namespaces with target namespace",
 			targetNamespace: "testing1",
@@ -1045,6 +1096,7 @@ func testIngressEndpoints(t *testing.T) {
 				ti.combineFQDNAndAnnotation,
 				ti.ignoreHostnameAnnotation,
 				ti.ignoreIngressTLSSpec,
+				0,
 			)
 			for _, ingress := range ingresses {
 				_, err := fakeClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Create(context.Background(), ingress, metav1.CreateOptions{})
@@ -1088,6 +1140,39 @@ func testIngressEndpoints(t *testing.T) {
 }
 
 // ingress specific helper functions
+// TestIngressAnnotationFilterWithAnnotationPrefix tests that the annotation filter
+// is applied to the annotations of ingresses after their prefix is translated.
+func TestIngressAnnotationFilterWithAnnotationPrefix(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	for _, ingress := range []*v1beta1.Ingress{
+		fakeIngress{
+			name:        "custom",
+			namespace:   "default",
+			ips:         []string{"1.2.3.4"},
+			annotations: map[string]string{"dns.example.com/hostname": "custom.example.org"},
+		}.Ingress(),
+		fakeIngress{
+			name:        "default",
+			namespace:   "default",
+			ips:         []string{"1.2.3.5"},
+			annotations: map[string]string{hostnameAnnotationKey: "default.example.org"},
+		}.Ingress(),
+	} {
+		_, err := fakeClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Create(context.Background(), ingress, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewIngressSource(fakeClient, "", hostnameAnnotationKey, "", false, false, false, 0)
+	require.NoError(t, err)
+	require.NoError(t, src.(*ingressSource).setAnnotationPrefix("dns.example.com"))
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "custom.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
 type fakeIngress struct {
 	dnsnames    []string
 	tlsdnsnames [][]string
@@ -20,18 +20,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
-	"time"
 
 	"github.com/pkg/errors"
 	contour "github.com/projectcontour/contour/apis/contour/v1beta1"
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
@@ -41,53 +42,87 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
+// routeAnnotationPrefix is the format of the key prefix of ingressroute annotations which apply to a single route.
+const routeAnnotationPrefix = "route-%d."
+
+// defaultContourNamespace is the namespace of a contour load balancer service given without one
+// when the source is not restricted to a namespace.
+const defaultContourNamespace = "heptio-contour"
+
 // ingressRouteSource is an implementation of Source for ProjectContour IngressRoute objects.
 // The IngressRoute implementation uses the spec.virtualHost.fqdn value for the hostname.
 // Use targetAnnotationKey to explicitly set Endpoint.
 type ingressRouteSource struct {
-	dynamicKubeClient          dynamic.Interface
-	kubeClient                 kubernetes.Interface
-	contourLoadBalancerService string
-	namespace                  string
-	annotationFilter           string
-	fqdnTemplate               *template.Template
-	combineFQDNAnnotation      bool
-	ignoreHostnameAnnotation   bool
-	ingressRouteInformer       informers.GenericInformer
-	unstructuredConverter      *UnstructuredConverter
+	dynamicKubeClient        dynamic.Interface
+	kubeClient               kubernetes.Interface
+	loadBalancerServices     []types.NamespacedName
+	namespace                string
+	annotationFilter         string
+	labelSelector            labels.Selector
+	fqdnTemplate             *template.Template
+	fqdnSuffix               string
+	emitWWWAlias             bool
+	combineFQDNAnnotation    bool
+	ignoreHostnameAnnotation bool
+	replaceFQDNWithHostnames bool
+	publishInvalid           bool
+	nodePortTargets          bool
+	nodeAddressType          v1.NodeAddressType
+	targetLookupRetries      int
+	ingressRouteInformer     informers.GenericInformer
+	unstructuredConverter    *UnstructuredConverter
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewContourIngressRouteSource creates a new contourIngressRouteSource with the given config.
-func NewContourIngressRouteSource(
-	dynamicKubeClient dynamic.Interface,
-	kubeClient kubernetes.Interface,
-	contourLoadBalancerService string,
-	namespace string,
-	annotationFilter string,
-	fqdnTemplate string,
-	combineFqdnAnnotation bool,
-	ignoreHostnameAnnotation bool,
-) (Source, error) {
+func NewContourIngressRouteSource(dynamicKubeClient dynamic.Interface, kubeClient kubernetes.Interface, cfg *Config) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(cfg.FQDNTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	labelSelector, err := labels.Parse(cfg.LabelFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	replaceFQDNWithHostnames, err := parseHostnameAnnotationStrategy(cfg.HostnameAnnotationStrategy)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, _, err = parseContourLoadBalancerService(contourLoadBalancerService); err != nil {
+	annotationPrefix, err := parseAnnotationPrefix(cfg.AnnotationPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancerServices, err := parseContourLoadBalancerServices(cfg.ContourLoadBalancerService, cfg.Namespace)
+	if err != nil {
 		return nil, err
 	}
 
+	preferredAddressType := v1.NodeExternalIP
+	if cfg.ContourNodeAddressType != "" {
+		preferredAddressType = v1.NodeAddressType(cfg.ContourNodeAddressType)
+		if preferredAddressType != v1.NodeExternalIP && preferredAddressType != v1.NodeInternalIP {
+			return nil, fmt.Errorf("invalid node address type '%v', must be %s or %s", cfg.ContourNodeAddressType, v1.NodeExternalIP, v1.NodeInternalIP)
+		}
+	}
+
+	uc, err := NewUnstructuredConverter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup Unstructured Converter: %v", err)
+	}
+
 	// Use shared informer to listen for add/update/delete of ingressroutes in the specified namespace.
 	// Set resync period to 0, to prevent processing when nothing has changed.
-	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, cfg.Namespace, nil)
 	ingressRouteInformer := informerFactory.ForResource(contour.IngressRouteGVR)
 
 	// Add default resource event handlers to properly initialize informer.
@@ -98,89 +133,135 @@ func NewContourIngressRouteSource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return ingressRouteInformer.Informer().HasSynced(), nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
-	}
-
-	uc, err := NewUnstructuredConverter()
+	err = waitForCacheSync("contour-ingressroute", "IngressRoute", cfg.CacheSyncTimeout, ingressRouteInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to setup Unstructured Converter: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	return &ingressRouteSource{
-		dynamicKubeClient:          dynamicKubeClient,
-		kubeClient:                 kubeClient,
-		contourLoadBalancerService: contourLoadBalancerService,
-		namespace:                  namespace,
-		annotationFilter:           annotationFilter,
-		fqdnTemplate:               tmpl,
-		combineFQDNAnnotation:      combineFqdnAnnotation,
-		ignoreHostnameAnnotation:   ignoreHostnameAnnotation,
-		ingressRouteInformer:       ingressRouteInformer,
-		unstructuredConverter:      uc,
+		annotationPrefixer:       annotationPrefixer{annotationPrefix: annotationPrefix},
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("contour-ingressroute", ingressRouteInformer.Informer().HasSynced),
+		dynamicKubeClient:        dynamicKubeClient,
+		kubeClient:               kubeClient,
+		loadBalancerServices:     loadBalancerServices,
+		namespace:                cfg.Namespace,
+		annotationFilter:         cfg.AnnotationFilter,
+		labelSelector:            labelSelector,
+		fqdnTemplate:             tmpl,
+		fqdnSuffix:               strings.Trim(cfg.ContourFQDNSuffix, "."),
+		emitWWWAlias:             cfg.ContourEmitWWWAlias,
+		combineFQDNAnnotation:    cfg.CombineFQDNAndAnnotation,
+		ignoreHostnameAnnotation: cfg.IgnoreHostnameAnnotation,
+		replaceFQDNWithHostnames: replaceFQDNWithHostnames,
+		publishInvalid:           cfg.ContourPublishInvalid,
+		nodePortTargets:          cfg.ContourNodePortTargets,
+		nodeAddressType:          preferredAddressType,
+		targetLookupRetries:      cfg.TargetLookupRetries,
+		ingressRouteInformer:     ingressRouteInformer,
+		unstructuredConverter:    uc,
 	}, nil
 }
 
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all ingressroute resources in the source's namespace(s).
 func (sc *ingressRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	irs, err := sc.ingressRouteInformer.Lister().ByNamespace(sc.namespace).List(labels.Everything())
+	endpoints, _, err := sc.EndpointsWithWarnings(ctx)
+	return endpoints, err
+}
+
+// EndpointsWithWarnings returns the endpoints of the source along with a warning for each ingressroute
+// it skipped, e.g. because it is not valid, has no targets or belongs to another controller.
+func (sc *ingressRouteSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	metrics := newSourceMetrics("contour-ingressroute")
+
+	// The roots of delegates are looked up regardless of the label filter, which only selects the ingressroutes to publish.
+	allIngressRoutes, err := sc.listIngressRoutes(labels.Everything())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	roots := delegationRoots(allIngressRoutes)
 
-	// Convert to []*contour.IngressRoute
+	// A delegate ingressroute has no virtual host of its own, it is served under the fqdn of its root,
+	// so the root is published along with it, once and subject to the same checks.
 	var ingressRoutes []*contour.IngressRoute
-	for _, ir := range irs {
-		unstrucuredIR, ok := ir.(*unstructured.Unstructured)
+	selected := map[string]bool{}
+	for _, ir := range allIngressRoutes {
+		if sc.labelSelector.Matches(labels.Set(ir.Labels)) {
+			ingressRoutes = append(ingressRoutes, ir)
+			selected[ir.Namespace+"/"+ir.Name] = true
+		}
+	}
+	for _, ir := range ingressRoutes {
+		if ir.Spec.VirtualHost != nil {
+			continue
+		}
+		root, ok := roots[ir.Namespace+"/"+ir.Name]
 		if !ok {
-			return nil, errors.New("could not convert")
+			log.Debugf("No root found for delegate ingressroute %s/%s", ir.Namespace, ir.Name)
+			continue
 		}
-
-		irConverted := &contour.IngressRoute{}
-		err := sc.unstructuredConverter.scheme.Convert(unstrucuredIR, irConverted, nil)
-		if err != nil {
-			return nil, err
+		if key := root.Namespace + "/" + root.Name; !selected[key] {
+			ingressRoutes = append(ingressRoutes, root)
+			selected[key] = true
 		}
-		ingressRoutes = append(ingressRoutes, irConverted)
 	}
 
 	ingressRoutes, err = sc.filterByAnnotations(ingressRoutes)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	endpoints := []*endpoint.Endpoint{}
+	var warnings []Warning
 
 	for _, ir := range ingressRoutes {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		logger := log.WithFields(log.Fields{"source": "ingressroute", "namespace": ir.Namespace, "name": ir.Name})
+		resource := fmt.Sprintf("ingressroute/%s/%s", ir.Namespace, ir.Name)
+
 		// Check controller annotation to see if we are responsible.
 		controller, ok := ir.Annotations[controllerAnnotationKey]
 		if ok && controller != controllerAnnotationValue {
-			log.Debugf("Skipping ingressroute %s/%s because controller value does not match, found: %s, required: %s",
-				ir.Namespace, ir.Name, controller, controllerAnnotationValue)
+			logger.Debugf("Skipping ingressroute because controller value does not match, found: %s, required: %s",
+				controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonController, Message: fmt.Sprintf("controller value %q does not match %q", controller, controllerAnnotationValue)})
+			continue
+		} else if err := checkTargetAnnotation(ir.Annotations); err != nil {
+			logger.Warnf("Skipping ingressroute: %v", err)
+			metrics.skip(skipReasonInvalid)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonInvalid, Message: err.Error()})
 			continue
-		} else if ir.CurrentStatus != "valid" {
-			log.Debugf("Skipping ingressroute %s/%s because it is not valid", ir.Namespace, ir.Name)
+		} else if !sc.isValid(ir) {
+			logger.Debug("Skipping ingressroute because it is not valid")
+			metrics.skip(skipReasonInvalid)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonInvalid, Message: fmt.Sprintf("status %q is not valid", ir.CurrentStatus)})
 			continue
 		}
 
-		irEndpoints, err := sc.endpointsFromIngressRoute(ctx, ir)
+		ttls := sc.specTTLs(ir)
+		irEndpoints, err := sc.endpointsFromIngressRoute(ctx, ir, sc.additionalFQDNs(ir), ttls)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		// apply template if fqdn is missing on ingressroute
 		if (sc.combineFQDNAnnotation || len(irEndpoints) == 0) && sc.fqdnTemplate != nil {
-			tmplEndpoints, err := sc.endpointsFromTemplate(ctx, ir)
+			tmplEndpoints, err := sc.endpointsFromTemplate(ctx, ir, ttls)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if sc.combineFQDNAnnotation {
@@ -190,13 +271,20 @@ func (sc *ingressRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoi
 			}
 		}
 
+		// The fqdn and the hostname annotation may name the same host.
+		irEndpoints = MergeEndpointsByNameType(irEndpoints)
+
 		if len(irEndpoints) == 0 {
-			log.Debugf("No endpoints could be generated from ingressroute %s/%s", ir.Namespace, ir.Name)
+			logger.Debug("No endpoints could be generated from ingressroute")
+			metrics.skip(skipReasonNoEndpoints)
+			warnings = append(warnings, Warning{Resource: resource, Reason: skipReasonNoEndpoints, Message: "no endpoints could be generated, e.g. because no hostnames or targets were found"})
 			continue
 		}
 
-		log.Debugf("Endpoints generated from ingressroute: %s/%s: %v", ir.Namespace, ir.Name, irEndpoints)
+		logger.Debugf("Endpoints generated from ingressroute: %v", irEndpoints)
 		sc.setResourceLabel(ir, irEndpoints)
+		setDualstackLabel(ir.Annotations, fmt.Sprintf("ingressroute %s/%s", ir.Namespace, ir.Name), irEndpoints)
+		setOwnerIDLabel(ir.Annotations, irEndpoints)
 		endpoints = append(endpoints, irEndpoints...)
 	}
 
@@ -204,10 +292,67 @@ func (sc *ingressRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoi
 		sort.Sort(ep.Targets)
 	}
 
-	return endpoints, nil
+	metrics.publish(endpoints)
+	return endpoints, warnings, nil
+}
+
+// listIngressRoutes returns all ingressroute resources in the source's namespace(s) matching the selector.
+func (sc *ingressRouteSource) listIngressRoutes(selector labels.Selector) ([]*contour.IngressRoute, error) {
+	irs, err := sc.ingressRouteInformer.Lister().ByNamespace(sc.namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to []*contour.IngressRoute
+	var ingressRoutes []*contour.IngressRoute
+	for _, ir := range irs {
+		unstrucuredIR, ok := ir.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.New("could not convert")
+		}
+
+		irConverted := &contour.IngressRoute{}
+		err := sc.unstructuredConverter.scheme.Convert(unstrucuredIR, irConverted, nil)
+		if err != nil {
+			return nil, err
+		}
+		irConverted.Annotations = ensureAnnotations(irConverted.Annotations)
+		irConverted.Annotations = sc.translateIngressRouteAnnotations(irConverted.Annotations)
+		ingressRoutes = append(ingressRoutes, irConverted)
+	}
+
+	return ingressRoutes, nil
+}
+
+// additionalFQDNs returns the additional fqdns of the virtual host of the given ingressroute,
+// which are read from its unstructured object in the informer cache.
+func (sc *ingressRouteSource) additionalFQDNs(ingressRoute *contour.IngressRoute) []string {
+	obj, err := sc.ingressRouteInformer.Lister().ByNamespace(ingressRoute.Namespace).Get(ingressRoute.Name)
+	if err != nil {
+		return nil
+	}
+	unstructuredIR, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	return additionalContourFQDNs(unstructuredIR)
+}
+
+// specTTLs returns the TTLs which newer variants of IngressRoute declare in spec.ttl and spec.routes[].ttl,
+// which are read from the unstructured object of the given ingressroute in the informer cache.
+func (sc *ingressRouteSource) specTTLs(ingressRoute *contour.IngressRoute) ingressRouteTTLs {
+	obj, err := sc.ingressRouteInformer.Lister().ByNamespace(ingressRoute.Namespace).Get(ingressRoute.Name)
+	if err != nil {
+		return ingressRouteTTLs{}
+	}
+	unstructuredIR, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return ingressRouteTTLs{}
+	}
+	return specTTLs(unstructuredIR)
 }
 
-func (sc *ingressRouteSource) endpointsFromTemplate(ctx context.Context, ingressRoute *contour.IngressRoute) ([]*endpoint.Endpoint, error) {
+func (sc *ingressRouteSource) endpointsFromTemplate(ctx context.Context, ingressRoute *contour.IngressRoute, ttls ingressRouteTTLs) ([]*endpoint.Endpoint, error) {
 	// Process the whole template string
 	var buf bytes.Buffer
 	err := sc.fqdnTemplate.Execute(&buf, ingressRoute)
@@ -221,6 +366,7 @@ func (sc *ingressRouteSource) endpointsFromTemplate(ctx context.Context, ingress
 	if err != nil {
 		log.Warn(err)
 	}
+	ttl = ttls.recordTTL(ttl)
 
 	targets := getTargetsFromTargetAnnotation(ingressRoute.Annotations)
 
@@ -232,13 +378,18 @@ func (sc *ingressRouteSource) endpointsFromTemplate(ctx context.Context, ingress
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ingressRoute.Annotations)
+	recordType := getRecordTypeFromAnnotations(ingressRoute.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping ingressroute %s/%s: %v", ingressRoute.Namespace, ingressRoute.Name, err)
+		return nil, nil
+	}
 
 	var endpoints []*endpoint.Endpoint
 	// splits the FQDN template and removes the trailing periods
 	hostnameList := strings.Split(strings.Replace(hostnames, " ", "", -1), ",")
 	for _, hostname := range hostnameList {
 		hostname = strings.TrimSuffix(hostname, ".")
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 	return endpoints, nil
 }
@@ -280,20 +431,36 @@ func (sc *ingressRouteSource) setResourceLabel(ingressRoute *contour.IngressRout
 	}
 }
 
+// targetsFromContourLoadBalancer returns the union of the targets of all contour load balancer services in order.
 func (sc *ingressRouteSource) targetsFromContourLoadBalancer(ctx context.Context) (targets endpoint.Targets, err error) {
-	lbNamespace, lbName, err := parseContourLoadBalancerService(sc.contourLoadBalancerService)
-	if err != nil {
-		return nil, err
+	seen := map[string]bool{}
+	addTarget := func(target string) {
+		if target != "" && !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
 	}
-	if svc, err := sc.kubeClient.CoreV1().Services(lbNamespace).Get(ctx, lbName, metav1.GetOptions{}); err != nil {
-		log.Warn(err)
-	} else {
+
+	for _, lbService := range sc.loadBalancerServices {
+		svc, err := getServiceWithRetries(ctx, sc.kubeClient, lbService.Namespace, lbService.Name, sc.targetLookupRetries)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
 		for _, lb := range svc.Status.LoadBalancer.Ingress {
-			if lb.IP != "" {
-				targets = append(targets, lb.IP)
+			addTarget(lb.IP)
+			addTarget(lb.Hostname)
+		}
+
+		// On bare metal envoy often runs as a DaemonSet behind a NodePort service without any load balancer.
+		if sc.nodePortTargets && svc.Spec.Type == v1.ServiceTypeNodePort {
+			nodeTargets, err := sc.targetsFromNodes(ctx, svc)
+			if err != nil {
+				log.Warn(err)
+				continue
 			}
-			if lb.Hostname != "" {
-				targets = append(targets, lb.Hostname)
+			for _, target := range nodeTargets {
+				addTarget(target)
 			}
 		}
 	}
@@ -301,9 +468,66 @@ func (sc *ingressRouteSource) targetsFromContourLoadBalancer(ctx context.Context
 	return
 }
 
+// targetsFromNodes returns the addresses of the nodes backing the NodePort service, preferring addresses of
+// the configured type. Like the service source, only nodes running pods of the service are used if its
+// external traffic policy is Local, in which case a service without a selector has no node targets.
+func (sc *ingressRouteSource) targetsFromNodes(ctx context.Context, svc *v1.Service) (endpoint.Targets, error) {
+	nodes, err := sc.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeNames map[string]bool
+	if svc.Spec.ExternalTrafficPolicy == v1.ServiceExternalTrafficPolicyTypeLocal {
+		// An empty selector would match every pod in the namespace, so the nodes backing a service
+		// without a selector are unknown.
+		if len(svc.Spec.Selector) == 0 {
+			log.Debugf("No node targets for service %s/%s with a Local external traffic policy and no selector", svc.Namespace, svc.Name)
+			return nil, nil
+		}
+		pods, err := sc.kubeClient.CoreV1().Pods(svc.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.Set(svc.Spec.Selector).String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		nodeNames = map[string]bool{}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == v1.PodRunning {
+				nodeNames[pod.Spec.NodeName] = true
+			}
+		}
+	}
+
+	var preferred, other endpoint.Targets
+	for _, node := range nodes.Items {
+		if nodeNames != nil && !nodeNames[node.Name] {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			switch {
+			case address.Type == sc.nodeAddressType:
+				preferred = append(preferred, address.Address)
+			case address.Type == v1.NodeExternalIP || address.Type == v1.NodeInternalIP:
+				other = append(other, address.Address)
+			}
+		}
+	}
+
+	if len(preferred) > 0 {
+		return preferred, nil
+	}
+	return other, nil
+}
+
+// isValid returns whether endpoints should be generated for the ingressroute given its status.
+func (sc *ingressRouteSource) isValid(ingressRoute *contour.IngressRoute) bool {
+	return sc.publishInvalid || strings.EqualFold(ingressRoute.CurrentStatus, "valid")
+}
+
 // endpointsFromIngressRouteConfig extracts the endpoints from a Contour IngressRoute object
-func (sc *ingressRouteSource) endpointsFromIngressRoute(ctx context.Context, ingressRoute *contour.IngressRoute) ([]*endpoint.Endpoint, error) {
-	if ingressRoute.CurrentStatus != "valid" {
+func (sc *ingressRouteSource) endpointsFromIngressRoute(ctx context.Context, ingressRoute *contour.IngressRoute, additionalFQDNs []string, ttls ingressRouteTTLs) ([]*endpoint.Endpoint, error) {
+	if !sc.isValid(ingressRoute) {
 		log.Warn(errors.Errorf("cannot generate endpoints for ingressroute with status %s", ingressRoute.CurrentStatus))
 		return nil, nil
 	}
@@ -314,6 +538,9 @@ func (sc *ingressRouteSource) endpointsFromIngressRoute(ctx context.Context, ing
 	if err != nil {
 		log.Warn(err)
 	}
+	ttl = ttls.ttl(ttl)
+	// The records of the virtual host serve all of its routes.
+	recordTTL := ttls.recordTTL(ttl)
 
 	targets := getTargetsFromTargetAnnotation(ingressRoute.Annotations)
 
@@ -325,33 +552,361 @@ func (sc *ingressRouteSource) endpointsFromIngressRoute(ctx context.Context, ing
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ingressRoute.Annotations)
-
-	if virtualHost := ingressRoute.Spec.VirtualHost; virtualHost != nil {
-		if fqdn := virtualHost.Fqdn; fqdn != "" {
-			endpoints = append(endpoints, endpointsForHostname(fqdn, targets, ttl, providerSpecific, setIdentifier)...)
-		}
+	recordType := getRecordTypeFromAnnotations(ingressRoute.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping ingressroute %s/%s: %v", ingressRoute.Namespace, ingressRoute.Name, err)
+		return nil, nil
 	}
 
 	// Skip endpoints if we do not want entries from annotations
+	var hostnameList []string
 	if !sc.ignoreHostnameAnnotation {
-		hostnameList := getHostnamesFromAnnotations(ingressRoute.Annotations)
-		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		hostnameList = getHostnamesFromAnnotations(ingressRoute.Annotations)
+	}
+
+	if sc.replaceFQDNWithHostnames && len(hostnameList) > 0 {
+		log.Debugf("Replacing the fqdn of ingressroute %s/%s with its hostname annotation", ingressRoute.Namespace, ingressRoute.Name)
+	} else if virtualHost := ingressRoute.Spec.VirtualHost; virtualHost != nil {
+		for _, fqdn := range contourFQDNs(virtualHost.Fqdn, additionalFQDNs, sc.fqdnSuffix) {
+			for _, hostname := range contourVirtualHostnames(fqdn, sc.emitWWWAlias) {
+				// Routes with a set identifier of their own replace the endpoint of the virtual host.
+				routeEndpoints := endpointsFromRoutes(ingressRoute, hostname, targets, ttl, ttls)
+				if len(routeEndpoints) > 0 {
+					endpoints = append(endpoints, routeEndpoints...)
+				} else {
+					endpoints = append(endpoints, endpointsForHostname(hostname, targets, recordTTL, providerSpecific, setIdentifier, recordType)...)
+				}
+			}
 		}
 	}
 
+	for _, hostname := range hostnameList {
+		hostname = strings.TrimSuffix(hostname, ".")
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, recordTTL, providerSpecific, setIdentifier, recordType)...)
+	}
+
 	return endpoints, nil
 }
 
-func parseContourLoadBalancerService(service string) (namespace, name string, err error) {
+// endpointsFromRoutes returns an endpoint for the fqdn of the ingressroute for each of its routes
+// with a set identifier declared in the route annotations of the ingressroute.
+// The TTL of a route is its spec TTL, falling back to its TTL annotation and then to the given TTL.
+func endpointsFromRoutes(ingressRoute *contour.IngressRoute, fqdn string, targets endpoint.Targets, ttl endpoint.TTL, ttls ingressRouteTTLs) []*endpoint.Endpoint {
+	var endpoints []*endpoint.Endpoint
+	for i, route := range ingressRoute.Spec.Routes {
+		annotations := routeAnnotations(ingressRoute.Annotations, i)
+		if len(annotations) == 0 {
+			continue
+		}
+		if annotations[SetIdentifierKey] == "" {
+			log.Debugf("Skipping annotations of route %q of ingressroute %s/%s because they have no set identifier",
+				route.Match, ingressRoute.Namespace, ingressRoute.Name)
+			continue
+		}
+
+		// the annotations of the route take precedence over those of the ingressroute
+		merged := make(map[string]string, len(ingressRoute.Annotations))
+		for k, v := range ingressRoute.Annotations {
+			merged[k] = v
+		}
+		for k, v := range annotations {
+			merged[k] = v
+		}
+
+		routeTTL := ttl
+		if _, ok := annotations[ttlAnnotationKey]; ok {
+			annotationTTL, err := getTTLFromAnnotations(annotations)
+			if err != nil {
+				log.Warn(err)
+			} else {
+				routeTTL = annotationTTL
+			}
+		}
+		routeTTL = ttls.routeTTL(i, routeTTL)
+		providerSpecific, setIdentifier := getProviderSpecificAnnotations(merged)
+		recordType := getRecordTypeFromAnnotations(merged)
+		endpoints = append(endpoints, endpointsForHostname(fqdn, targets, routeTTL, providerSpecific, setIdentifier, recordType)...)
+	}
+	return endpoints
+}
+
+// ingressRouteTTLs holds the TTLs which newer variants of IngressRoute declare in spec.ttl and spec.routes[].ttl.
+// They take precedence over the TTL annotations. A TTL which is not declared is zero.
+type ingressRouteTTLs struct {
+	spec   endpoint.TTL
+	routes map[int]endpoint.TTL
+}
+
+// specTTLs reads the TTLs of the spec of the unstructured ingressroute, as the fields are not part of the typed IngressRoute.
+func specTTLs(unstructuredIR *unstructured.Unstructured) ingressRouteTTLs {
+	ttls := ingressRouteTTLs{}
+	if ttl, ok := specTTL(unstructuredIR.Object, "spec", "ttl"); ok {
+		ttls.spec = ttl
+	}
+	routes, _, _ := unstructured.NestedSlice(unstructuredIR.Object, "spec", "routes")
+	for i, route := range routes {
+		if route, ok := route.(map[string]interface{}); ok {
+			if ttl, ok := specTTL(route, "ttl"); ok {
+				if ttls.routes == nil {
+					ttls.routes = map[int]endpoint.TTL{}
+				}
+				ttls.routes[i] = ttl
+			}
+		}
+	}
+	return ttls
+}
+
+// ttl returns the spec TTL of the ingressroute, falling back to the given TTL of its annotations.
+func (t ingressRouteTTLs) ttl(annotationTTL endpoint.TTL) endpoint.TTL {
+	if t.spec != 0 {
+		return t.spec
+	}
+	return annotationTTL
+}
+
+// recordTTL returns the TTL of a record serving all routes of the ingressroute, which is the lowest
+// spec TTL of its routes, falling back to the spec TTL of the ingressroute and then to the given TTL.
+func (t ingressRouteTTLs) recordTTL(annotationTTL endpoint.TTL) endpoint.TTL {
+	var lowest endpoint.TTL
+	for _, ttl := range t.routes {
+		if lowest == 0 || ttl < lowest {
+			lowest = ttl
+		}
+	}
+	if lowest != 0 {
+		return lowest
+	}
+	return t.ttl(annotationTTL)
+}
+
+// routeTTL returns the spec TTL of the route at the given index, falling back to the given TTL.
+func (t ingressRouteTTLs) routeTTL(index int, ttl endpoint.TTL) endpoint.TTL {
+	if routeTTL, ok := t.routes[index]; ok {
+		return routeTTL
+	}
+	return ttl
+}
+
+// specTTL returns the TTL at the given path of the object, accepting both a number of seconds
+// and a duration string.
+func specTTL(obj map[string]interface{}, fields ...string) (endpoint.TTL, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj, fields...)
+	if err != nil || !found {
+		return 0, false
+	}
+	var ttl int64
+	switch v := value.(type) {
+	case int64:
+		ttl = v
+	case float64:
+		ttl = int64(v)
+	case string:
+		if ttl, err = parseTTL(v); err != nil {
+			log.Warnf("Ignoring invalid TTL %q", v)
+			return 0, false
+		}
+	default:
+		log.Debugf("Ignoring TTL of unexpected type %T", value)
+		return 0, false
+	}
+	if ttl < ttlMinimum || ttl > ttlMaximum {
+		log.Warnf("Ignoring TTL %d, which must be between [%d, %d]", ttl, ttlMinimum, ttlMaximum)
+		return 0, false
+	}
+	return endpoint.TTL(ttl), true
+}
+
+// routeAnnotations returns the annotations of the ingressroute which apply to its route at the given index,
+// with the route prefix removed from their keys, e.g. "route-0.external-dns.alpha.kubernetes.io/set-identifier"
+// for the first route.
+func routeAnnotations(annotations map[string]string, index int) map[string]string {
+	prefix := fmt.Sprintf(routeAnnotationPrefix, index)
+
+	routeAnnotations := map[string]string{}
+	for k, v := range annotations {
+		if strings.HasPrefix(k, prefix) {
+			routeAnnotations[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return routeAnnotations
+}
+
+// routeAnnotationKeyPrefix matches the route prefix of the keys of ingressroute annotations which apply to a single route.
+var routeAnnotationKeyPrefix = regexp.MustCompile(`^route-\d+\.`)
+
+// translateIngressRouteAnnotations is like translateAnnotations, but also translates the keys of the
+// annotations which apply to a single route behind their route prefix, e.g. "route-0.dns.example.com/ttl".
+func (sc *ingressRouteSource) translateIngressRouteAnnotations(annotations map[string]string) map[string]string {
+	translated, ok := sc.translateAnnotations(annotations)
+	if !ok {
+		return annotations
+	}
+
+	routes := map[string]map[string]string{}
+	for k, v := range translated {
+		prefix := routeAnnotationKeyPrefix.FindString(k)
+		if prefix == "" {
+			continue
+		}
+		delete(translated, k)
+		if routes[prefix] == nil {
+			routes[prefix] = map[string]string{}
+		}
+		routes[prefix][strings.TrimPrefix(k, prefix)] = v
+	}
+	for prefix, routeAnnotations := range routes {
+		routeAnnotations, _ = sc.translateAnnotations(routeAnnotations)
+		for k, v := range routeAnnotations {
+			translated[prefix+k] = v
+		}
+	}
+	return translated
+}
+
+// delegationRoots returns the root ingressroute, which defines the virtual host, of each delegate ingressroute
+// by following the delegate references of the given ingressroutes upwards. The roots are keyed by the namespace
+// and name of the delegates. Orphaned delegates and delegates which are part of a delegation cycle have no root.
+func delegationRoots(ingressRoutes []*contour.IngressRoute) map[string]*contour.IngressRoute {
+	parents := map[string]*contour.IngressRoute{}
+	for _, ir := range ingressRoutes {
+		for _, route := range ir.Spec.Routes {
+			if route.Delegate == nil {
+				continue
+			}
+			// the delegate namespace defaults to the namespace of the delegating ingressroute
+			namespace := route.Delegate.Namespace
+			if namespace == "" {
+				namespace = ir.Namespace
+			}
+			if key := namespace + "/" + route.Delegate.Name; parents[key] == nil {
+				parents[key] = ir
+			}
+		}
+	}
+
+	roots := map[string]*contour.IngressRoute{}
+	for _, delegate := range ingressRoutes {
+		if delegate.Spec.VirtualHost != nil {
+			continue
+		}
+		visited := map[string]bool{}
+		current := delegate
+		for current != nil && current.Spec.VirtualHost == nil {
+			key := current.Namespace + "/" + current.Name
+			if visited[key] {
+				log.Debugf("Delegation cycle detected for ingressroute %s/%s", delegate.Namespace, delegate.Name)
+				current = nil
+				break
+			}
+			visited[key] = true
+			current = parents[key]
+		}
+		if current != nil {
+			roots[delegate.Namespace+"/"+delegate.Name] = current
+		}
+	}
+	return roots
+}
+
+// qualifyContourFQDN removes the trailing dot of the given virtual host fqdn and appends the suffix,
+// if any, to an fqdn without dots. Qualified fqdns are returned unchanged.
+func qualifyContourFQDN(fqdn, suffix string) string {
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	if fqdn == "" || suffix == "" || strings.Contains(fqdn, ".") {
+		return fqdn
+	}
+	return fqdn + "." + suffix
+}
+
+// contourFQDNs returns the sorted, unique and qualified fqdns of a Contour virtual host:
+// its fqdn along with the given additional ones.
+func contourFQDNs(fqdn string, additional []string, suffix string) []string {
+	seen := map[string]bool{}
+	var fqdns []string
+	for _, name := range append([]string{fqdn}, additional...) {
+		if name = qualifyContourFQDN(strings.TrimSpace(name), suffix); name != "" && !seen[name] {
+			seen[name] = true
+			fqdns = append(fqdns, name)
+		}
+	}
+	sort.Strings(fqdns)
+	return fqdns
+}
+
+// additionalContourFQDNs returns the names listed in spec.virtualhost.fqdns of the given Contour resource.
+// The field is not part of the typed resources, so it is read from the unstructured object.
+func additionalContourFQDNs(obj *unstructured.Unstructured) []string {
+	fqdns, _, err := unstructured.NestedStringSlice(obj.Object, "spec", "virtualhost", "fqdns")
+	if err != nil {
+		log.Debugf("%s %s/%s: ignoring invalid spec.virtualhost.fqdns: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	return fqdns
+}
+
+// contourVirtualHostnames returns the given virtual host fqdn along with its www. alias if requested
+// and the fqdn is an apex domain, i.e. it has a single dot and doesn't start with www. already.
+func contourVirtualHostnames(fqdn string, wwwAlias bool) []string {
+	if !wwwAlias || strings.Count(fqdn, ".") != 1 || strings.HasPrefix(fqdn, "www.") {
+		return []string{fqdn}
+	}
+	return []string{fqdn, "www." + fqdn}
+}
+
+// Strategies for combining the hostname annotation of a Contour resource with the fqdn of its virtual host.
+const (
+	// HostnameAnnotationStrategyAppend publishes the hostnames of the annotation along with the fqdn.
+	HostnameAnnotationStrategyAppend = "append"
+	// HostnameAnnotationStrategyReplace publishes the hostnames of the annotation instead of the fqdn.
+	HostnameAnnotationStrategyReplace = "replace"
+)
+
+// parseHostnameAnnotationStrategy returns whether the hostname annotation replaces the fqdn
+// with the given strategy. The empty strategy appends.
+func parseHostnameAnnotationStrategy(strategy string) (bool, error) {
+	switch strategy {
+	case "", HostnameAnnotationStrategyAppend:
+		return false, nil
+	case HostnameAnnotationStrategyReplace:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid hostname annotation strategy '%v', must be %s or %s", strategy, HostnameAnnotationStrategyAppend, HostnameAnnotationStrategyReplace)
+	}
+}
+
+// parseContourLoadBalancerServices parses a comma-separated list of load balancer services.
+func parseContourLoadBalancerServices(services, defaultNamespace string) ([]types.NamespacedName, error) {
+	var names []types.NamespacedName
+	for _, service := range strings.Split(services, ",") {
+		namespace, name, err := parseContourLoadBalancerService(strings.TrimSpace(service), defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, types.NamespacedName{Namespace: namespace, Name: name})
+	}
+	return names, nil
+}
+
+// parseContourLoadBalancerService parses a load balancer service given as either
+// namespace/name or a bare name. A bare name is looked up in defaultNamespace or,
+// if that is empty, in the heptio-contour namespace.
+func parseContourLoadBalancerService(service, defaultNamespace string) (namespace, name string, err error) {
 	parts := strings.Split(service, "/")
-	if len(parts) != 2 {
-		err = fmt.Errorf("invalid contour load balancer service (namespace/name) found '%v'", service)
-	} else {
+	switch len(parts) {
+	case 1:
+		namespace, name = defaultNamespace, parts[0]
+		if namespace == "" {
+			namespace = defaultContourNamespace
+		}
+	case 2:
 		namespace, name = parts[0], parts[1]
 	}
+	if len(parts) > 2 || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid contour load balancer service (namespace/name or name) found '%v'", service)
+	}
 
-	return
+	return namespace, name, nil
 }
 
 func (sc *ingressRouteSource) AddEventHandler(ctx context.Context, handler func()) {
@@ -18,6 +18,7 @@ package source
 
 import (
 	"context"
+	"sort"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -61,16 +62,11 @@ func (suite *IngressRouteSuite) SetupTest() {
 	_, err = fakeKubernetesClient.CoreV1().Services(suite.loadBalancer.Namespace).Create(context.Background(), suite.loadBalancer, metav1.CreateOptions{})
 	suite.NoError(err, "should succeed")
 
-	suite.source, err = NewContourIngressRouteSource(
-		fakeDynamicClient,
-		fakeKubernetesClient,
-		"heptio-contour/contour",
-		"default",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
+	suite.source, err = NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+		FQDNTemplate:               "{{.Name}}",
+	})
 	suite.NoError(err, "should initialize ingressroute source")
 
 	suite.ingressRoute = (fakeIngressRoute{
@@ -115,6 +111,21 @@ func TestIngressRoute(t *testing.T) {
 	suite.Run(t, new(IngressRouteSuite))
 	t.Run("endpointsFromIngressRoute", testEndpointsFromIngressRoute)
 	t.Run("Endpoints", testIngressRouteEndpoints)
+	t.Run("RouteAnnotations", testIngressRouteRouteAnnotations)
+	t.Run("parseContourLoadBalancerService", testParseContourLoadBalancerService)
+	t.Run("qualifyContourFQDN", testQualifyContourFQDN)
+	t.Run("HostnameAnnotationStrategy", testIngressRouteHostnameAnnotationStrategy)
+	t.Run("WWWAlias", testIngressRouteWWWAlias)
+	t.Run("WithoutAnnotations", testIngressRouteWithoutAnnotations)
+	t.Run("SpecTTL", testIngressRouteSpecTTL)
+	t.Run("SpecTTLWithAnnotationPrefix", testIngressRouteSpecTTLWithAnnotationPrefix)
+	t.Run("RouteAnnotationsWithAnnotationPrefix", testIngressRouteRouteAnnotationsWithAnnotationPrefix)
+	t.Run("MultipleFQDNs", testIngressRouteMultipleFQDNs)
+	t.Run("MultipleLoadBalancers", testIngressRouteMultipleLoadBalancers)
+	t.Run("DualstackLabel", testIngressRouteDualstackLabel)
+	t.Run("OwnerIDLabel", testIngressRouteOwnerIDLabel)
+	t.Run("HostnameAnnotationMatchingFQDN", testIngressRouteHostnameAnnotationMatchingFQDN)
+	t.Run("NodePortTargets", testIngressRouteNodePortTargets)
 }
 
 func TestNewContourIngressRouteSource(t *testing.T) {
@@ -159,16 +170,12 @@ func TestNewContourIngressRouteSource(t *testing.T) {
 		t.Run(ti.title, func(t *testing.T) {
 			fakeDynamicClient, _ := newDynamicKubernetesClient()
 
-			_, err := NewContourIngressRouteSource(
-				fakeDynamicClient,
-				fakeKube.NewSimpleClientset(),
-				"heptio-contour/contour",
-				"",
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				false,
-			)
+			_, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+				ContourLoadBalancerService: "heptio-contour/contour",
+				AnnotationFilter:           ti.annotationFilter,
+				FQDNTemplate:               ti.fqdnTemplate,
+				CombineFQDNAndAnnotation:   ti.combineFQDNAndAnnotation,
+			})
 			if ti.expectError {
 				assert.Error(t, err)
 			} else {
@@ -215,6 +222,28 @@ func testEndpointsFromIngressRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "fqdn and hostname annotation with trailing dots",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRoute: fakeIngressRoute{
+				host: "foo.bar.",
+				annotations: map[string]string{
+					hostnameAnnotationKey: "baz.bar.",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "foo.bar",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+				{
+					DNSName: "baz.bar",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 		{
 			title: "one rule.host two lb.IP and two lb.Hostname",
 			loadBalancer: fakeLoadBalancerService{
@@ -276,7 +305,7 @@ func testEndpointsFromIngressRoute(t *testing.T) {
 		t.Run(ti.title, func(t *testing.T) {
 			if source, err := newTestIngressRouteSource(ti.loadBalancer); err != nil {
 				require.NoError(t, err)
-			} else if endpoints, err := source.endpointsFromIngressRoute(context.Background(), ti.ingressRoute.IngressRoute()); err != nil {
+			} else if endpoints, err := source.endpointsFromIngressRoute(context.Background(), ti.ingressRoute.IngressRoute(), nil, ingressRouteTTLs{}); err != nil {
 				require.NoError(t, err)
 			} else {
 				validateEndpoints(t, endpoints, ti.expected)
@@ -291,6 +320,7 @@ func testIngressRouteEndpoints(t *testing.T) {
 		title                    string
 		targetNamespace          string
 		annotationFilter         string
+		labelFilter              string
 		loadBalancer             fakeLoadBalancerService
 		ingressRouteItems        []fakeIngressRoute
 		expected                 []*endpoint.Endpoint
@@ -298,6 +328,7 @@ func testIngressRouteEndpoints(t *testing.T) {
 		fqdnTemplate             string
 		combineFQDNAndAnnotation bool
 		ignoreHostnameAnnotation bool
+		publishInvalid           bool
 	}{
 		{
 			title:           "no ingressroute",
@@ -515,6 +546,57 @@ func testIngressRouteEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{},
 		},
+		{
+			title:           "valid matching label filter",
+			targetNamespace: "",
+			labelFilter:     "team=payments",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					labels: map[string]string{
+						"team": "payments",
+					},
+					host: "example.org",
+				},
+				{
+					name:      "fake2",
+					namespace: namespace,
+					labels: map[string]string{
+						"team": "search",
+					},
+					host: "new.example.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "valid non-matching label filter",
+			targetNamespace: "",
+			labelFilter:     "team=payments",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					annotations: map[string]string{
+						"team": "payments",
+					},
+					host: "example.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
 		{
 			title:           "our controller type is dns-controller",
 			targetNamespace: "",
@@ -997,6 +1079,238 @@ func testIngressRouteEndpoints(t *testing.T) {
 			},
 			ignoreHostnameAnnotation: true,
 		},
+		{
+			title:           "ingressroute with uppercase valid status",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					status:    "Valid",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:           "invalid ingressroute",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					invalid:   true,
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:           "invalid ingressroute with publish invalid",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					host:      "example.org",
+					invalid:   true,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+			publishInvalid: true,
+		},
+		{
+			title:           "delegate ingressroute uses fqdn and targets of its root",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "root",
+					namespace: namespace,
+					annotations: map[string]string{
+						targetAnnotationKey: "root.lb.com",
+					},
+					host: "example.org",
+					delegates: []contour.Delegate{
+						{Name: "middle"},
+					},
+				},
+				{
+					name:      "middle",
+					namespace: namespace,
+					delegate:  true,
+					delegates: []contour.Delegate{
+						{Name: "child", Namespace: "other"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: "other",
+					delegate:  true,
+				},
+				{
+					name:      "orphan",
+					namespace: namespace,
+					delegate:  true,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"root.lb.com"},
+				},
+			},
+		},
+		{
+			title:           "delegate publishes its root regardless of the label filter",
+			targetNamespace: "",
+			labelFilter:     "team=payments",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					delegates: []contour.Delegate{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					labels:    map[string]string{"team": "payments"},
+					delegate:  true,
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName: "example.org",
+					Targets: endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
+		{
+			title:            "delegate does not publish a root excluded by the annotation filter",
+			targetNamespace:  "",
+			annotationFilter: "kubernetes.io/ingress.class=contour",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					delegates: []contour.Delegate{
+						{Name: "child"},
+					},
+				},
+				{
+					name:        "child",
+					namespace:   namespace,
+					annotations: map[string]string{"kubernetes.io/ingress.class": "contour"},
+					delegate:    true,
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:           "delegate does not publish a root of another controller",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:        "root",
+					namespace:   namespace,
+					annotations: map[string]string{controllerAnnotationKey: "some-other-tool"},
+					host:        "example.org",
+					delegates: []contour.Delegate{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:           "delegate does not publish an invalid root",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					invalid:   true,
+					delegates: []contour.Delegate{
+						{Name: "child"},
+					},
+				},
+				{
+					name:      "child",
+					namespace: namespace,
+					delegate:  true,
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:           "delegation cycle",
+			targetNamespace: "",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			ingressRouteItems: []fakeIngressRoute{
+				{
+					name:      "first",
+					namespace: namespace,
+					delegate:  true,
+					delegates: []contour.Delegate{
+						{Name: "second"},
+					},
+				},
+				{
+					name:      "second",
+					namespace: namespace,
+					delegate:  true,
+					delegates: []contour.Delegate{
+						{Name: "first"},
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{},
+		},
 	} {
 		t.Run(ti.title, func(t *testing.T) {
 			ingressRoutes := make([]*contour.IngressRoute, 0)
@@ -1006,7 +1320,7 @@ func testIngressRouteEndpoints(t *testing.T) {
 
 			fakeKubernetesClient := fakeKube.NewSimpleClientset()
 
-			lbService := ti.loadBalancer.Service()
+			lbService := ti.loadBalancer.contourService()
 			_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
 			if err != nil {
 				require.NoError(t, err)
@@ -1020,16 +1334,16 @@ func testIngressRouteEndpoints(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			ingressRouteSource, err := NewContourIngressRouteSource(
-				fakeDynamicClient,
-				fakeKubernetesClient,
-				lbService.Namespace+"/"+lbService.Name,
-				ti.targetNamespace,
-				ti.annotationFilter,
-				ti.fqdnTemplate,
-				ti.combineFQDNAndAnnotation,
-				ti.ignoreHostnameAnnotation,
-			)
+			ingressRouteSource, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+				ContourLoadBalancerService: lbService.Namespace + "/" + lbService.Name,
+				Namespace:                  ti.targetNamespace,
+				AnnotationFilter:           ti.annotationFilter,
+				LabelFilter:                ti.labelFilter,
+				FQDNTemplate:               ti.fqdnTemplate,
+				CombineFQDNAndAnnotation:   ti.combineFQDNAndAnnotation,
+				IgnoreHostnameAnnotation:   ti.ignoreHostnameAnnotation,
+				ContourPublishInvalid:      ti.publishInvalid,
+			})
 			require.NoError(t, err)
 
 			res, err := ingressRouteSource.Endpoints(context.Background())
@@ -1044,27 +1358,775 @@ func testIngressRouteEndpoints(t *testing.T) {
 	}
 }
 
+func testIngressRouteRouteAnnotations(t *testing.T) {
+	source, err := newTestIngressRouteSource(fakeLoadBalancerService{
+		hostnames: []string{"lb.com"},
+	})
+	require.NoError(t, err)
+
+	for _, ti := range []struct {
+		title        string
+		ingressRoute fakeIngressRoute
+		expected     []*endpoint.Endpoint
+	}{
+		{
+			title: "weighted routes",
+			ingressRoute: fakeIngressRoute{
+				host: "foo.bar",
+				annotations: map[string]string{
+					"external-dns.alpha.kubernetes.io/alias":                  "true",
+					"route-0.external-dns.alpha.kubernetes.io/set-identifier": "blue",
+					"route-0.external-dns.alpha.kubernetes.io/aws-weight":     "90",
+					"route-1.external-dns.alpha.kubernetes.io/set-identifier": "green",
+					"route-1.external-dns.alpha.kubernetes.io/aws-weight":     "10",
+				},
+				routes: []contour.Route{{Match: "/"}, {Match: "/canary"}},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:       "foo.bar",
+					Targets:       endpoint.Targets{"lb.com"},
+					RecordType:    endpoint.RecordTypeCNAME,
+					SetIdentifier: "blue",
+					ProviderSpecific: endpoint.ProviderSpecific{
+						{Name: "alias", Value: "true"},
+						{Name: "aws/weight", Value: "90"},
+					},
+				},
+				{
+					DNSName:       "foo.bar",
+					Targets:       endpoint.Targets{"lb.com"},
+					RecordType:    endpoint.RecordTypeCNAME,
+					SetIdentifier: "green",
+					ProviderSpecific: endpoint.ProviderSpecific{
+						{Name: "alias", Value: "true"},
+						{Name: "aws/weight", Value: "10"},
+					},
+				},
+			},
+		},
+		{
+			title: "route annotations without set identifier",
+			ingressRoute: fakeIngressRoute{
+				host: "foo.bar",
+				annotations: map[string]string{
+					"route-0.external-dns.alpha.kubernetes.io/aws-weight": "90",
+				},
+				routes: []contour.Route{{Match: "/"}},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "foo.bar",
+					Targets:          endpoint.Targets{"lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					ProviderSpecific: endpoint.ProviderSpecific{},
+				},
+			},
+		},
+		{
+			title: "annotations of missing route",
+			ingressRoute: fakeIngressRoute{
+				host: "foo.bar",
+				annotations: map[string]string{
+					"route-1.external-dns.alpha.kubernetes.io/set-identifier": "green",
+				},
+				routes: []contour.Route{{Match: "/"}},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:          "foo.bar",
+					Targets:          endpoint.Targets{"lb.com"},
+					RecordType:       endpoint.RecordTypeCNAME,
+					ProviderSpecific: endpoint.ProviderSpecific{},
+				},
+			},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			endpoints, err := source.endpointsFromIngressRoute(context.Background(), ti.ingressRoute.IngressRoute(), nil, ingressRouteTTLs{})
+			require.NoError(t, err)
+			require.Len(t, endpoints, len(ti.expected))
+			sort.Slice(endpoints, func(i, j int) bool {
+				return endpoints[i].SetIdentifier < endpoints[j].SetIdentifier
+			})
+			for i, ep := range endpoints {
+				sort.Slice(ep.ProviderSpecific, func(i, j int) bool {
+					return ep.ProviderSpecific[i].Name < ep.ProviderSpecific[j].Name
+				})
+				assert.Equal(t, ti.expected[i].DNSName, ep.DNSName)
+				assert.Equal(t, ti.expected[i].Targets, ep.Targets)
+				assert.Equal(t, ti.expected[i].RecordType, ep.RecordType)
+				assert.Equal(t, ti.expected[i].SetIdentifier, ep.SetIdentifier)
+				assert.Equal(t, ti.expected[i].ProviderSpecific, ep.ProviderSpecific)
+			}
+		})
+	}
+}
+
+func testIngressRouteHostnameAnnotationStrategy(t *testing.T) {
+	ingressRoute := fakeIngressRoute{
+		namespace: "default",
+		name:      "renamed",
+		host:      "old.example.org",
+		annotations: map[string]string{
+			hostnameAnnotationKey: "new.example.org",
+			targetAnnotationKey:   "lb.example.org",
+		},
+	}.IngressRoute()
+
+	for _, ti := range []struct {
+		strategy string
+		expected []*endpoint.Endpoint
+	}{
+		{
+			strategy: HostnameAnnotationStrategyAppend,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "old.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+				{DNSName: "new.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			strategy: HostnameAnnotationStrategyReplace,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "new.example.org", Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+	} {
+		t.Run(ti.strategy, func(t *testing.T) {
+			replace, err := parseHostnameAnnotationStrategy(ti.strategy)
+			require.NoError(t, err)
+			src := &ingressRouteSource{replaceFQDNWithHostnames: replace}
+
+			endpoints, err := src.endpointsFromIngressRoute(context.Background(), ingressRoute, nil, ingressRouteTTLs{})
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+func testIngressRouteWWWAlias(t *testing.T) {
+	src := &ingressRouteSource{emitWWWAlias: true}
+
+	for _, ti := range []struct {
+		host     string
+		expected []string
+	}{
+		{host: "example.com", expected: []string{"example.com", "www.example.com"}},
+		{host: "www.example.com", expected: []string{"www.example.com"}},
+	} {
+		t.Run(ti.host, func(t *testing.T) {
+			ingressRoute := fakeIngressRoute{
+				namespace:   "default",
+				name:        "fake",
+				host:        ti.host,
+				annotations: map[string]string{targetAnnotationKey: "lb.example.org"},
+			}.IngressRoute()
+
+			endpoints, err := src.endpointsFromIngressRoute(context.Background(), ingressRoute, nil, ingressRouteTTLs{})
+			require.NoError(t, err)
+			var expected []*endpoint.Endpoint
+			for _, name := range ti.expected {
+				expected = append(expected, &endpoint.Endpoint{DNSName: name, Targets: endpoint.Targets{"lb.example.org"}, RecordType: endpoint.RecordTypeCNAME})
+			}
+			validateEndpoints(t, endpoints, expected)
+		})
+	}
+}
+
+func testQualifyContourFQDN(t *testing.T) {
+	for _, ti := range []struct {
+		fqdn     string
+		suffix   string
+		expected string
+	}{
+		{fqdn: "app", suffix: "example.com", expected: "app.example.com"},
+		{fqdn: "app.", suffix: "example.com", expected: "app.example.com"},
+		{fqdn: "app.example.org", suffix: "example.com", expected: "app.example.org"},
+		{fqdn: "app.example.com.", suffix: "example.com", expected: "app.example.com"},
+		{fqdn: "app", suffix: "", expected: "app"},
+		{fqdn: "", suffix: "example.com", expected: ""},
+	} {
+		assert.Equal(t, ti.expected, qualifyContourFQDN(ti.fqdn, ti.suffix), "fqdn %q with suffix %q", ti.fqdn, ti.suffix)
+	}
+}
+
+func testParseContourLoadBalancerService(t *testing.T) {
+	for _, ti := range []struct {
+		title            string
+		service          string
+		defaultNamespace string
+		namespace        string
+		name             string
+		expectError      bool
+	}{
+		{
+			title:     "namespaced name",
+			service:   "ingress/contour",
+			namespace: "ingress",
+			name:      "contour",
+		},
+		{
+			title:            "namespaced name ignores default namespace",
+			service:          "ingress/contour",
+			defaultNamespace: "default",
+			namespace:        "ingress",
+			name:             "contour",
+		},
+		{
+			title:            "bare name uses default namespace",
+			service:          "contour",
+			defaultNamespace: "ingress",
+			namespace:        "ingress",
+			name:             "contour",
+		},
+		{
+			title:     "bare name without default namespace",
+			service:   "contour",
+			namespace: "heptio-contour",
+			name:      "contour",
+		},
+		{
+			title:       "too many slashes",
+			service:     "heptio-contour/contour/extra",
+			expectError: true,
+		},
+		{
+			title:       "missing name",
+			service:     "heptio-contour/",
+			expectError: true,
+		},
+		{
+			title:       "empty",
+			service:     "",
+			expectError: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			namespace, name, err := parseContourLoadBalancerService(ti.service, ti.defaultNamespace)
+			if ti.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, ti.namespace, namespace)
+			assert.Equal(t, ti.name, name)
+		})
+	}
+}
+
+func testIngressRouteMultipleLoadBalancers(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	fakeDynamicClient, _ := newDynamicKubernetesClient()
+
+	for _, lb := range []fakeLoadBalancerService{
+		{namespace: "heptio-contour", name: "contour-zone-a", ips: []string{"1.2.3.4", "5.6.7.8"}},
+		{namespace: "default", name: "contour-zone-b", ips: []string{"5.6.7.8", "8.8.8.8"}},
+	} {
+		_, err := fakeKubernetesClient.CoreV1().Services(lb.namespace).Create(context.Background(), lb.Service(), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour-zone-a, contour-zone-b",
+		Namespace:                  "default",
+	})
+	require.NoError(t, err)
+
+	targets, err := src.(*ingressRouteSource).targetsFromContourLoadBalancer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.Targets{"1.2.3.4", "5.6.7.8", "8.8.8.8"}, targets)
+}
+
+func testIngressRouteDualstackLabel(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		hostnames: []string{"lb.com"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace:   "default",
+		name:        "dualstack",
+		host:        "example.org",
+		annotations: map[string]string{ALBDualstackAnnotationKey: ALBDualstackAnnotationValue},
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+	})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "true", endpoints[0].Labels[endpoint.DualstackLabelKey])
+}
+
+func testIngressRouteSpecTTL(t *testing.T) {
+	_, scheme := newDynamicKubernetesClient()
+	src := &ingressRouteSource{}
+
+	for _, ti := range []struct {
+		title       string
+		annotations map[string]string
+		specTTL     interface{}
+		routeTTL    interface{}
+		expectedTTL endpoint.TTL
+	}{
+		{
+			title:       "annotation without spec TTL",
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			expectedTTL: 60,
+		},
+		{
+			title:       "spec TTL in seconds",
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			specTTL:     int64(120),
+			expectedTTL: 120,
+		},
+		{
+			title:       "spec TTL as duration",
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			specTTL:     "2m",
+			expectedTTL: 120,
+		},
+		{
+			title: "route spec TTL",
+			annotations: map[string]string{
+				"route-0.external-dns.alpha.kubernetes.io/set-identifier": "canary",
+				"route-0.external-dns.alpha.kubernetes.io/ttl":            "30",
+			},
+			specTTL:     int64(120),
+			routeTTL:    int64(90),
+			expectedTTL: 90,
+		},
+		{
+			title:       "route spec TTL without set identifier",
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			specTTL:     int64(120),
+			routeTTL:    "1m30s",
+			expectedTTL: 90,
+		},
+		{
+			title:       "invalid spec TTL",
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+			specTTL:     "forever",
+			expectedTTL: 60,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			annotations := map[string]string{targetAnnotationKey: "lb.example.org"}
+			for k, v := range ti.annotations {
+				annotations[k] = v
+			}
+			ingressRoute := fakeIngressRoute{
+				namespace:   "default",
+				name:        "ttl",
+				host:        "example.org",
+				annotations: annotations,
+				routes:      []contour.Route{{Match: "/"}},
+			}.IngressRoute()
+			unstructuredIR, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+			require.NoError(t, err)
+			if ti.specTTL != nil {
+				require.NoError(t, unstructured.SetNestedField(unstructuredIR.Object, ti.specTTL, "spec", "ttl"))
+			}
+			if ti.routeTTL != nil {
+				routes, _, err := unstructured.NestedSlice(unstructuredIR.Object, "spec", "routes")
+				require.NoError(t, err)
+				routes[0].(map[string]interface{})["ttl"] = ti.routeTTL
+				require.NoError(t, unstructured.SetNestedSlice(unstructuredIR.Object, routes, "spec", "routes"))
+			}
+
+			endpoints, err := src.endpointsFromIngressRoute(context.Background(), ingressRoute, nil, specTTLs(unstructuredIR))
+			require.NoError(t, err)
+			require.Len(t, endpoints, 1)
+			assert.Equal(t, ti.expectedTTL, endpoints[0].RecordTTL)
+		})
+	}
+}
+
+func testIngressRouteSpecTTLWithAnnotationPrefix(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace: "default",
+		name:      "ttl",
+		host:      "example.org",
+		annotations: map[string]string{
+			"dns.example.com/target": "lb.example.org",
+			"dns.example.com/ttl":    "60",
+		},
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(converted.Object, int64(120), "spec", "ttl"))
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+		AnnotationPrefix:           "dns.example.com",
+	})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, endpoint.TTL(120), endpoints[0].RecordTTL)
+}
+
+func testIngressRouteRouteAnnotationsWithAnnotationPrefix(t *testing.T) {
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace: "default",
+		name:      "weighted",
+		host:      "example.org",
+		annotations: map[string]string{
+			"dns.example.com/target":                                  "lb.example.org",
+			"route-0.dns.example.com/set-identifier":                  "blue",
+			"route-0.dns.example.com/aws-weight":                      "90",
+			"route-1.external-dns.alpha.kubernetes.io/set-identifier": "green",
+		},
+		routes: []contour.Route{{Match: "/"}, {Match: "/canary"}},
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKube.NewSimpleClientset(), &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+		AnnotationPrefix:           "dns.example.com",
+	})
+	require.NoError(t, err)
+
+	// The route annotations of the default prefix are left to other deployments.
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:          "example.org",
+			Targets:          endpoint.Targets{"lb.example.org"},
+			RecordType:       endpoint.RecordTypeCNAME,
+			SetIdentifier:    "blue",
+			ProviderSpecific: endpoint.ProviderSpecific{{Name: "aws/weight", Value: "90"}},
+		},
+	})
+}
+
+func testIngressRouteMultipleFQDNs(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		ips:       []string{"8.8.8.8"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace: "default",
+		name:      "multi",
+		host:      "foo.example.org",
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedStringSlice(converted.Object, []string{"bar.example.org", "foo.example.org"}, "spec", "virtualhost", "fqdns"))
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+	})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func TestContourFQDNs(t *testing.T) {
+	assert.Equal(t, []string{"bar.example.org", "foo.example.org"}, contourFQDNs("foo.example.org", []string{"foo.example.org.", "bar.example.org", ""}, ""))
+	assert.Equal(t, []string{"bar.example.org", "foo.example.org"}, contourFQDNs("", []string{"foo", "bar"}, "example.org"))
+	assert.Nil(t, contourFQDNs("", nil, ""))
+}
+
+func testIngressRouteWithoutAnnotations(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		hostnames: []string{"lb.com"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace: "default",
+		name:      "bare",
+		host:      "example.org",
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, ti := range []struct {
+		annotationFilter string
+		expected         []*endpoint.Endpoint
+	}{
+		{
+			annotationFilter: "",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "example.org", Targets: endpoint.Targets{"lb.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			annotationFilter: "kubernetes.io/ingress.class in (contour)",
+			expected:         []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(ti.annotationFilter, func(t *testing.T) {
+			src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+				ContourLoadBalancerService: "heptio-contour/contour",
+				Namespace:                  "default",
+				AnnotationFilter:           ti.annotationFilter,
+			})
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+		})
+	}
+}
+
+func testIngressRouteOwnerIDLabel(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		hostnames: []string{"lb.com"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace:   "default",
+		name:        "owned",
+		host:        "example.org",
+		annotations: map[string]string{ownerIDAnnotationKey: "team-a"},
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+	})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "team-a", endpoints[0].Labels[endpoint.OwnerIDLabelKey])
+}
+
+func testIngressRouteHostnameAnnotationMatchingFQDN(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		ips:       []string{"8.8.8.8"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	fakeDynamicClient, scheme := newDynamicKubernetesClient()
+	ingressRoute := fakeIngressRoute{
+		namespace:   "default",
+		name:        "same",
+		host:        "example.org",
+		annotations: map[string]string{hostnameAnnotationKey: "example.org"},
+	}.IngressRoute()
+	converted, err := convertIngressRouteToUnstructured(ingressRoute, scheme)
+	require.NoError(t, err)
+	_, err = fakeDynamicClient.Resource(contour.IngressRouteGVR).Namespace(ingressRoute.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: "heptio-contour/contour",
+		Namespace:                  "default",
+	})
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
+
+func testIngressRouteNodePortTargets(t *testing.T) {
+	node := func(name string, addresses ...v1.NodeAddress) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.NodeStatus{Addresses: addresses},
+		}
+	}
+	dualNodes := []*v1.Node{
+		node("node1", v1.NodeAddress{Type: v1.NodeExternalIP, Address: "54.10.11.1"}, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.1.1"}),
+		node("node2", v1.NodeAddress{Type: v1.NodeExternalIP, Address: "54.10.11.2"}, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.1.2"}),
+	}
+	internalNodes := []*v1.Node{
+		node("node1", v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.1.1"}),
+		node("node2", v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.1.2"}),
+	}
+
+	runningPod := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "heptio-contour", Name: name, Labels: map[string]string{"app": "envoy"}},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+	}
+	otherPod := runningPod("other", "node2")
+	otherPod.Labels = map[string]string{"app": "other"}
+
+	for _, ti := range []struct {
+		title           string
+		serviceType     v1.ServiceType
+		trafficPolicy   v1.ServiceExternalTrafficPolicyType
+		selector        map[string]string
+		pods            []*v1.Pod
+		nodes           []*v1.Node
+		nodePortTargets bool
+		nodeAddressType string
+		expected        endpoint.Targets
+	}{
+		{
+			title:           "external addresses by default",
+			serviceType:     v1.ServiceTypeNodePort,
+			nodes:           dualNodes,
+			nodePortTargets: true,
+			expected:        endpoint.Targets{"54.10.11.1", "54.10.11.2"},
+		},
+		{
+			title:           "preferred internal addresses",
+			serviceType:     v1.ServiceTypeNodePort,
+			nodes:           dualNodes,
+			nodePortTargets: true,
+			nodeAddressType: "InternalIP",
+			expected:        endpoint.Targets{"10.0.1.1", "10.0.1.2"},
+		},
+		{
+			title:           "falls back to the other address type",
+			serviceType:     v1.ServiceTypeNodePort,
+			nodes:           internalNodes,
+			nodePortTargets: true,
+			nodeAddressType: "ExternalIP",
+			expected:        endpoint.Targets{"10.0.1.1", "10.0.1.2"},
+		},
+		{
+			title:           "local policy uses the nodes running pods of the service",
+			serviceType:     v1.ServiceTypeNodePort,
+			trafficPolicy:   v1.ServiceExternalTrafficPolicyTypeLocal,
+			selector:        map[string]string{"app": "envoy"},
+			pods:            []*v1.Pod{runningPod("envoy", "node1"), otherPod},
+			nodes:           dualNodes,
+			nodePortTargets: true,
+			expected:        endpoint.Targets{"54.10.11.1"},
+		},
+		{
+			title:           "local policy without a selector",
+			serviceType:     v1.ServiceTypeNodePort,
+			trafficPolicy:   v1.ServiceExternalTrafficPolicyTypeLocal,
+			pods:            []*v1.Pod{runningPod("envoy", "node1"), otherPod},
+			nodes:           dualNodes,
+			nodePortTargets: true,
+		},
+		{
+			title:       "disabled",
+			serviceType: v1.ServiceTypeNodePort,
+			nodes:       dualNodes,
+		},
+		{
+			title:           "not a NodePort service",
+			serviceType:     v1.ServiceTypeClusterIP,
+			nodes:           dualNodes,
+			nodePortTargets: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			fakeKubernetesClient := fakeKube.NewSimpleClientset()
+			fakeDynamicClient, _ := newDynamicKubernetesClient()
+
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "heptio-contour", Name: "contour"},
+				Spec:       v1.ServiceSpec{Type: ti.serviceType, ExternalTrafficPolicy: ti.trafficPolicy, Selector: ti.selector},
+			}
+			_, err := fakeKubernetesClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+			require.NoError(t, err)
+			for _, pod := range ti.pods {
+				_, err := fakeKubernetesClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			for _, node := range ti.nodes {
+				_, err := fakeKubernetesClient.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+				ContourLoadBalancerService: "heptio-contour/contour",
+				Namespace:                  "default",
+				ContourNodePortTargets:     ti.nodePortTargets,
+				ContourNodeAddressType:     ti.nodeAddressType,
+			})
+			require.NoError(t, err)
+
+			targets, err := src.(*ingressRouteSource).targetsFromContourLoadBalancer(context.Background())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, ti.expected, targets)
+		})
+	}
+}
+
 // ingressroute specific helper functions
 func newTestIngressRouteSource(loadBalancer fakeLoadBalancerService) (*ingressRouteSource, error) {
 	fakeKubernetesClient := fakeKube.NewSimpleClientset()
 	fakeDynamicClient, _ := newDynamicKubernetesClient()
 
-	lbService := loadBalancer.Service()
+	lbService := loadBalancer.contourService()
 	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	src, err := NewContourIngressRouteSource(
-		fakeDynamicClient,
-		fakeKubernetesClient,
-		lbService.Namespace+"/"+lbService.Name,
-		"default",
-		"",
-		"{{.Name}}",
-		false,
-		false,
-	)
+	src, err := NewContourIngressRouteSource(fakeDynamicClient, fakeKubernetesClient, &Config{
+		ContourLoadBalancerService: lbService.Namespace + "/" + lbService.Name,
+		Namespace:                  "default",
+		FQDNTemplate:               "{{.Name}}",
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1111,21 +2173,37 @@ func (ig fakeLoadBalancerService) Service() *v1.Service {
 	return svc
 }
 
+// contourService returns the load balancer service named heptio-contour/contour
+// unless the test names it otherwise.
+func (ig fakeLoadBalancerService) contourService() *v1.Service {
+	if ig.namespace == "" {
+		ig.namespace = "heptio-contour"
+	}
+	if ig.name == "" {
+		ig.name = "contour"
+	}
+	return ig.Service()
+}
+
 type fakeIngressRoute struct {
 	namespace   string
 	name        string
 	annotations map[string]string
+	labels      map[string]string
 
-	host     string
-	invalid  bool
-	delegate bool
+	host      string
+	status    string
+	invalid   bool
+	delegate  bool
+	delegates []contour.Delegate
+	routes    []contour.Route
 }
 
 func (ir fakeIngressRoute) IngressRoute() *contour.IngressRoute {
-	var status string
-	if ir.invalid {
+	status := ir.status
+	if status == "" && ir.invalid {
 		status = "invalid"
-	} else {
+	} else if status == "" {
 		status = "valid"
 	}
 
@@ -1140,11 +2218,20 @@ func (ir fakeIngressRoute) IngressRoute() *contour.IngressRoute {
 		}
 	}
 
+	spec.Routes = append(spec.Routes, ir.routes...)
+	for i := range ir.delegates {
+		spec.Routes = append(spec.Routes, contour.Route{
+			Match:    "/",
+			Delegate: &ir.delegates[i],
+		})
+	}
+
 	ingressRoute := &contour.IngressRoute{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:   ir.namespace,
 			Name:        ir.name,
 			Annotations: ir.annotations,
+			Labels:      ir.labels,
 		},
 		Spec: spec,
 		Status: projectcontour.Status{
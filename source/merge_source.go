@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// MergeEndpointsByNameType merges the endpoints with the same DNS name, record type and set identifier
+// into a single endpoint based on the first of them, with the union of their targets and the largest of their TTLs.
+// The order of the endpoints and of their targets is preserved. It can be used as an EndpointModifier.
+func MergeEndpointsByNameType(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	type key struct {
+		dnsName       string
+		recordType    string
+		setIdentifier string
+	}
+
+	result := []*endpoint.Endpoint{}
+	merged := map[key]*endpoint.Endpoint{}
+	collected := map[key]map[string]bool{}
+
+	for _, ep := range endpoints {
+		k := key{dnsName: ep.DNSName, recordType: ep.RecordType, setIdentifier: ep.SetIdentifier}
+
+		m, ok := merged[k]
+		if !ok {
+			// merge into a copy so that the given endpoints are left untouched
+			copied := *ep
+			copied.Targets = nil
+			m = &copied
+			merged[k] = m
+			collected[k] = map[string]bool{}
+			result = append(result, m)
+		}
+
+		if ep.RecordTTL > m.RecordTTL {
+			m.RecordTTL = ep.RecordTTL
+		}
+		for _, t := range ep.Targets {
+			if !collected[k][t] {
+				collected[k][t] = true
+				m.Targets = append(m.Targets, t)
+			}
+		}
+	}
+
+	return result
+}
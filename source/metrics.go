@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// The reasons for which sources skip resources.
+const (
+	// skipReasonController is used for resources whose controller annotation belongs to another controller.
+	skipReasonController = "controller"
+	// skipReasonInvalid is used for resources which are not valid.
+	skipReasonInvalid = "invalid"
+	// skipReasonNoAnnotation is used for resources missing an annotation the source requires.
+	skipReasonNoAnnotation = "no-annotation"
+	// skipReasonNoEndpoints is used for resources from which no endpoints could be generated.
+	skipReasonNoEndpoints = "no-endpoints"
+)
+
+// skipReasons are all reasons for which sources skip resources.
+var skipReasons = []string{skipReasonController, skipReasonInvalid, skipReasonNoAnnotation, skipReasonNoEndpoints}
+
+var (
+	endpointsGenerated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "source",
+			Name:      "endpoints_total",
+			Help:      "Number of Endpoints generated by each source.",
+		},
+		[]string{"source"},
+	)
+	resourcesSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "source",
+			Name:      "skipped_total",
+			Help:      "Number of resources skipped by each source, by reason.",
+		},
+		[]string{"source", "reason"},
+	)
+)
+
+// registerMetrics registers the source metrics with the given registerer.
+// Metrics which are already registered with it are left as they are, but it is
+// an error if another collector is registered with the same descriptor.
+func registerMetrics(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{endpointsGenerated, resourcesSkipped} {
+		if err := registerer.Register(c); err != nil {
+			if are, ok := err.(prometheus.AlreadyRegisteredError); !ok || are.ExistingCollector != c {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sourceMetrics tallies the resources skipped by a source during a single run,
+// and adds them to the counters of the source along with the generated endpoints once it is done.
+// Sources of the same name share their counters.
+type sourceMetrics struct {
+	source  string
+	skipped map[string]int
+}
+
+// newSourceMetrics returns the metrics of a new run of the named source.
+func newSourceMetrics(source string) *sourceMetrics {
+	return &sourceMetrics{source: source, skipped: make(map[string]int)}
+}
+
+// skip counts a resource skipped for the given reason.
+func (m *sourceMetrics) skip(reason string) {
+	m.skipped[reason]++
+}
+
+// publish adds the generated endpoints and the skipped resources of the run to the counters of the source.
+func (m *sourceMetrics) publish(endpoints []*endpoint.Endpoint) {
+	endpointsGenerated.WithLabelValues(m.source).Add(float64(len(endpoints)))
+	for _, reason := range skipReasons {
+		resourcesSkipped.WithLabelValues(m.source, reason).Add(float64(m.skipped[reason]))
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSourceMetrics(t *testing.T) {
+	// The counters are shared by all sources, so drop what other tests have counted.
+	endpointsGenerated.Reset()
+	resourcesSkipped.Reset()
+
+	kubeClient := fakeKube.NewSimpleClientset()
+	for _, svc := range []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "foo",
+				Annotations: map[string]string{hostnameAnnotationKey: "foo.example.org,bar.example.org"},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "other",
+				Annotations: map[string]string{
+					hostnameAnnotationKey:   "other.example.org",
+					controllerAnnotationKey: "other-controller",
+				},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "unnamed",
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		},
+	} {
+		_, err := kubeClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(kubeClient, nil)
+
+	registry := prometheus.NewRegistry()
+	cfg := &Config{MetricsRegisterer: registry}
+	sources, err := ByNamesWithConfigs(mockClientGenerator, []NamedConfig{{Name: "service", Config: cfg}, {Name: "service", Config: cfg}})
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+
+	// Sources of the same name add to the same counters.
+	for i, source := range sources {
+		_, err = source.Endpoints(context.Background())
+		require.NoError(t, err)
+
+		runs := float64(i + 1)
+		assert.Equal(t, 2*runs, gatherCounter(t, registry, "source_endpoints_total", map[string]string{"source": "service"}))
+		assert.Equal(t, runs, gatherCounter(t, registry, "source_skipped_total", map[string]string{"source": "service", "reason": skipReasonController}))
+		assert.Equal(t, runs, gatherCounter(t, registry, "source_skipped_total", map[string]string{"source": "service", "reason": skipReasonNoEndpoints}))
+		assert.Equal(t, float64(0), gatherCounter(t, registry, "source_skipped_total", map[string]string{"source": "service", "reason": skipReasonInvalid}))
+	}
+
+	// Registering the metrics again is harmless.
+	require.NoError(t, registerMetrics(registry))
+}
+
+func TestRegisterMetricsConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(prometheus.NewCounterVec(
+		prometheus.CounterOpts{Subsystem: "source", Name: "endpoints_total", Help: "Number of Endpoints generated by each source."},
+		[]string{"source"},
+	)))
+
+	assert.Error(t, registerMetrics(registry), "should not register the metrics if another collector is registered with the same descriptor")
+}
+
+// gatherCounter returns the value of the counter with the given name and labels in the registry,
+// or zero if there is no such counter.
+func gatherCounter(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// EnforceMinTTL returns an EndpointModifier raising the configured TTLs of the endpoints below the minimum.
+// Endpoints without a TTL are left to the provider default.
+func EnforceMinTTL(ttl endpoint.TTL) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		for _, ep := range endpoints {
+			if ep.RecordTTL.IsConfigured() && ep.RecordTTL < ttl {
+				log.Debugf("Raising TTL %d of endpoint %s of resource %q to the minimum of %d", ep.RecordTTL, ep.DNSName, ep.Labels[endpoint.ResourceLabelKey], ttl)
+				ep.RecordTTL = ttl
+			}
+		}
+		return endpoints
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// EndpointModifier changes the endpoints produced by a source, e.g. to add common labels
+// or to rewrite targets. It may modify the given endpoints in place and returns the result.
+type EndpointModifier func([]*endpoint.Endpoint) []*endpoint.Endpoint
+
+// modifiedSource is a Source that applies modifiers to the endpoints of its wrapped source.
+type modifiedSource struct {
+	source    Source
+	modifiers []EndpointModifier
+}
+
+// NewModifiedSource creates a new modifiedSource wrapping the provided Source.
+// The modifiers are applied in order; nil modifiers are ignored.
+func NewModifiedSource(source Source, modifiers ...EndpointModifier) Source {
+	ms := &modifiedSource{source: source}
+	for _, modifier := range modifiers {
+		if modifier != nil {
+			ms.modifiers = append(ms.modifiers, modifier)
+		}
+	}
+	return ms
+}
+
+// Endpoints collects endpoints from its wrapped source and applies the modifiers to them.
+// The modifiers are not applied if the wrapped source fails.
+func (ms *modifiedSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, _, err := ms.EndpointsWithWarnings(ctx)
+	return endpoints, err
+}
+
+// EndpointsWithWarnings collects endpoints and warnings from its wrapped source and applies the
+// modifiers to the endpoints. The warnings are returned as they are.
+func (ms *modifiedSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	endpoints, warnings, err := EndpointsWithWarnings(ctx, ms.source)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, modifier := range ms.modifiers {
+		endpoints = modifier(endpoints)
+	}
+	return endpoints, warnings, nil
+}
+
+func (ms *modifiedSource) AddEventHandler(ctx context.Context, handler func()) {
+	ms.source.AddEventHandler(ctx, handler)
+}
+
+// Close closes the wrapped source if it can be closed.
+func (ms *modifiedSource) Close() error {
+	return closeSource(ms.source)
+}
+
+// Healthy reports the health of the wrapped source.
+func (ms *modifiedSource) Healthy() error {
+	return CheckHealth(ms.source)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that modifiedSource is a Source
+var _ Source = &modifiedSource{}
+
+func TestModifiedSource(t *testing.T) {
+	t.Run("Endpoints", testModifiedSourceEndpoints)
+	t.Run("Order", testModifiedSourceOrder)
+	t.Run("Error", testModifiedSourceError)
+	t.Run("Warnings", testModifiedSourceWarnings)
+}
+
+// stampLabel returns a modifier setting the given label on every endpoint.
+func stampLabel(key, value string) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		for _, ep := range endpoints {
+			if ep.Labels == nil {
+				ep.Labels = endpoint.NewLabels()
+			}
+			ep.Labels[key] = value
+		}
+		return endpoints
+	}
+}
+
+// testModifiedSourceEndpoints tests that the modifiers apply to every endpoint and nil modifiers are ignored.
+func testModifiedSourceEndpoints(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.org"}, Labels: endpoint.Labels{"team": "dns"}},
+	}, nil)
+
+	endpoints, err := NewModifiedSource(mockSource, nil, stampLabel("cluster", "blue")).Endpoints(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, endpoint.Labels{"cluster": "blue"}, endpoints[0].Labels)
+	assert.Equal(t, endpoint.Labels{"team": "dns", "cluster": "blue"}, endpoints[1].Labels)
+	mockSource.AssertExpectations(t)
+}
+
+// testModifiedSourceOrder tests that the modifiers apply in order.
+func testModifiedSourceOrder(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+	}, nil)
+
+	dropAll := func([]*endpoint.Endpoint) []*endpoint.Endpoint { return nil }
+	endpoints, err := NewModifiedSource(mockSource, dropAll, stampLabel("cluster", "blue")).Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, endpoints)
+}
+
+// testModifiedSourceError tests that an error of the wrapped source is returned without applying the modifiers.
+func testModifiedSourceError(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return(nil, errors.New("boom"))
+
+	called := false
+	modifier := func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		called = true
+		return endpoints
+	}
+	endpoints, err := NewModifiedSource(mockSource, modifier).Endpoints(context.Background())
+	assert.EqualError(t, err, "boom")
+	assert.Nil(t, endpoints)
+	assert.False(t, called)
+}
+
+// warningSource is a Source reporting fixed endpoints and warnings.
+type warningSource struct {
+	Source
+	endpoints []*endpoint.Endpoint
+	warnings  []Warning
+}
+
+func (ws *warningSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	return ws.endpoints, nil
+}
+
+func (ws *warningSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	return ws.endpoints, ws.warnings, nil
+}
+
+// testModifiedSourceWarnings tests that the warnings of the wrapped source are forwarded.
+func testModifiedSourceWarnings(t *testing.T) {
+	inner := &warningSource{
+		Source:    NewEmptySource(),
+		endpoints: []*endpoint.Endpoint{{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}}},
+		warnings:  []Warning{{Resource: "HTTPProxy/default/bar", Reason: skipReasonNoEndpoints, Message: "no targets"}},
+	}
+
+	endpoints, warnings, err := EndpointsWithWarnings(context.Background(), NewModifiedSource(inner, stampLabel("cluster", "blue")))
+	require.NoError(t, err)
+
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, endpoint.Labels{"cluster": "blue"}, endpoints[0].Labels)
+	assert.Equal(t, inner.warnings, warnings)
+}
@@ -18,6 +18,9 @@ package source
 
 import (
 	"context"
+	"sync"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -43,13 +46,94 @@ func (ms *multiSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, err
 	return result, nil
 }
 
+// EndpointsWithWarnings collects endpoints and warnings of all nested Sources and returns them in single slices.
+func (ms *multiSource) EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error) {
+	result := []*endpoint.Endpoint{}
+	var warnings []Warning
+
+	for _, s := range ms.children {
+		endpoints, childWarnings, err := EndpointsWithWarnings(ctx, s)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result = append(result, endpoints...)
+		warnings = append(warnings, childWarnings...)
+	}
+
+	return result, warnings, nil
+}
+
 func (ms *multiSource) AddEventHandler(ctx context.Context, handler func()) {
 	for _, s := range ms.children {
 		s.AddEventHandler(ctx, handler)
 	}
 }
 
+// Close closes all nested Sources which can be closed.
+func (ms *multiSource) Close() error {
+	var errs []error
+	for _, s := range ms.children {
+		if err := closeSource(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Healthy reports all nested Sources which are unhealthy.
+func (ms *multiSource) Healthy() error {
+	var errs []error
+	for _, s := range ms.children {
+		if err := CheckHealth(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 // NewMultiSource creates a new multiSource.
 func NewMultiSource(children []Source) Source {
 	return &multiSource{children: children}
 }
+
+// ParallelEndpoints collects the endpoints of the given Sources concurrently, running at most
+// maxConcurrency of them at a time, or all of them if maxConcurrency isn't positive.
+// The endpoints are returned in the order of their Sources. If any Source fails, the endpoints
+// of the others are returned along with the error of the first failing Source in that order.
+func ParallelEndpoints(ctx context.Context, sources []Source, maxConcurrency int) ([]*endpoint.Endpoint, error) {
+	if maxConcurrency <= 0 || maxConcurrency > len(sources) {
+		maxConcurrency = len(sources)
+	}
+
+	results := make([][]*endpoint.Endpoint, len(sources))
+	errs := make([]error, len(sources))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < maxConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = sources[i].Endpoints(ctx)
+			}
+		}()
+	}
+	for i := range sources {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	combined := []*endpoint.Endpoint{}
+	for _, endpoints := range results {
+		combined = append(combined, endpoints...)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return combined, err
+		}
+	}
+	return combined, nil
+}
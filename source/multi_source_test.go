@@ -19,7 +19,10 @@ package source
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,6 +35,12 @@ func TestMultiSource(t *testing.T) {
 	t.Run("Interface", testMultiSourceImplementsSource)
 	t.Run("Endpoints", testMultiSourceEndpoints)
 	t.Run("EndpointsWithError", testMultiSourceEndpointsWithError)
+	t.Run("AddEventHandler", testMultiSourceAddEventHandler)
+}
+
+func TestParallelEndpoints(t *testing.T) {
+	t.Run("Endpoints", testParallelEndpoints)
+	t.Run("EndpointsWithError", testParallelEndpointsWithError)
 }
 
 // testMultiSourceImplementsSource tests that multiSource is a valid Source.
@@ -119,3 +128,85 @@ func testMultiSourceEndpointsWithError(t *testing.T) {
 	// Validate that the nested source was called.
 	src.AssertExpectations(t)
 }
+
+// handlerCountingSource is a Source which counts the event handlers added to it.
+type handlerCountingSource struct {
+	testutils.MockSource
+	handlers int
+}
+
+func (s *handlerCountingSource) AddEventHandler(ctx context.Context, handler func()) {
+	s.handlers++
+}
+
+// testMultiSourceAddEventHandler tests that an event handler is added to every nested source.
+func testMultiSourceAddEventHandler(t *testing.T) {
+	children := []*handlerCountingSource{{}, {}}
+
+	source := NewMultiSource([]Source{children[0], children[1]})
+	source.AddEventHandler(context.Background(), func() {})
+
+	for _, child := range children {
+		assert.Equal(t, 1, child.handlers)
+	}
+}
+
+// concurrencyTrackingSource is a Source which records the number of concurrent calls to Endpoints.
+type concurrencyTrackingSource struct {
+	testutils.MockSource
+	endpoints []*endpoint.Endpoint
+	err       error
+	running   *int32
+	maxSeen   *int32
+}
+
+func (s *concurrencyTrackingSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	running := atomic.AddInt32(s.running, 1)
+	defer atomic.AddInt32(s.running, -1)
+	for {
+		seen := atomic.LoadInt32(s.maxSeen)
+		if running <= seen || atomic.CompareAndSwapInt32(s.maxSeen, seen, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return s.endpoints, s.err
+}
+
+// testParallelEndpoints tests that the endpoints of all sources are returned in order
+// without exceeding the concurrency limit.
+func testParallelEndpoints(t *testing.T) {
+	var running, maxSeen int32
+	var sources []Source
+	var expected []*endpoint.Endpoint
+	for i := 0; i < 5; i++ {
+		ep := endpoint.NewEndpoint(fmt.Sprintf("%d.example.org", i), endpoint.RecordTypeA, "8.8.8.8")
+		sources = append(sources, &concurrencyTrackingSource{
+			endpoints: []*endpoint.Endpoint{ep},
+			running:   &running,
+			maxSeen:   &maxSeen,
+		})
+		expected = append(expected, ep)
+	}
+
+	endpoints, err := ParallelEndpoints(context.Background(), sources, 2)
+	require.NoError(t, err)
+	assert.Equal(t, expected, endpoints)
+	assert.True(t, maxSeen <= 2, "ran %d sources concurrently", maxSeen)
+}
+
+// testParallelEndpointsWithError tests that the endpoints of the other sources are returned
+// along with the error of the first failing source.
+func testParallelEndpointsWithError(t *testing.T) {
+	var running, maxSeen int32
+	ep := endpoint.NewEndpoint("example.org", endpoint.RecordTypeA, "8.8.8.8")
+	sources := []Source{
+		&concurrencyTrackingSource{endpoints: []*endpoint.Endpoint{ep}, running: &running, maxSeen: &maxSeen},
+		&concurrencyTrackingSource{err: errors.New("first error"), running: &running, maxSeen: &maxSeen},
+		&concurrencyTrackingSource{err: errors.New("second error"), running: &running, maxSeen: &maxSeen},
+	}
+
+	endpoints, err := ParallelEndpoints(context.Background(), sources, 2)
+	assert.EqualError(t, err, "first error")
+	assert.Equal(t, []*endpoint.Endpoint{ep}, endpoints)
+}
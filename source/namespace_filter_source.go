@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// NamespaceFilter holds glob patterns, as understood by path.Match, of the namespaces whose
+// resources are published. A namespace is allowed if it matches any of the allowed patterns,
+// or none are given, and none of the denied patterns.
+type NamespaceFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// IsConfigured returns true if the filter holds any patterns.
+func (f NamespaceFilter) IsConfigured() bool {
+	return len(f.Allow) > 0 || len(f.Deny) > 0
+}
+
+// Validate checks that all patterns of the filter are well-formed.
+func (f NamespaceFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Allow...), f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid namespace pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Match returns true if resources in the given namespace are allowed by the filter.
+func (f NamespaceFilter) Match(namespace string) bool {
+	if matchesAnyNamespacePattern(f.Deny, namespace) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchesAnyNamespacePattern(f.Allow, namespace)
+}
+
+func matchesAnyNamespacePattern(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unnamespacedResourceKinds are the kinds of the resource labels of resources without a namespace.
+var unnamespacedResourceKinds = map[string]bool{"file": true, "node": true}
+
+// resourceNamespace returns the namespace of the resource an endpoint was generated from.
+// The resource label of namespaced resources has the form "kind/namespace/name", where the
+// name may contain further slashes.
+func resourceNamespace(ep *endpoint.Endpoint) (string, bool) {
+	parts := strings.SplitN(ep.Labels[endpoint.ResourceLabelKey], "/", 3)
+	if len(parts) != 3 || unnamespacedResourceKinds[parts[0]] {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// FilterNamespaces returns an EndpointModifier dropping the endpoints which were generated from
+// resources in namespaces not allowed by the filter. Endpoints which aren't generated from
+// namespaced resources are always kept.
+func FilterNamespaces(namespaceFilter NamespaceFilter) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		result := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			if namespace, ok := resourceNamespace(ep); ok && !namespaceFilter.Match(namespace) {
+				log.Debugf("Dropping endpoint %s of resource %q because its namespace is not allowed", ep.DNSName, ep.Labels[endpoint.ResourceLabelKey])
+				continue
+			}
+			result = append(result, ep)
+		}
+		return result
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestNamespaceFilterSource(t *testing.T) {
+	t.Run("Match", testNamespaceFilterMatch)
+	t.Run("Validate", testNamespaceFilterValidate)
+	t.Run("Endpoints", testNamespaceFilterEndpoints)
+	t.Run("FileSource", testNamespaceFilterFileSource)
+	t.Run("ByNames", testNamespaceFilterByNames)
+}
+
+// testNamespaceFilterMatch tests the allow and deny patterns of a NamespaceFilter.
+func testNamespaceFilterMatch(t *testing.T) {
+	for _, tc := range []struct {
+		title     string
+		filter    NamespaceFilter
+		namespace string
+		expected  bool
+	}{
+		{
+			title:     "empty filter",
+			namespace: "default",
+			expected:  true,
+		},
+		{
+			title:     "denied namespace",
+			filter:    NamespaceFilter{Deny: []string{"kube-system", "istio-system"}},
+			namespace: "kube-system",
+			expected:  false,
+		},
+		{
+			title:     "namespace not denied",
+			filter:    NamespaceFilter{Deny: []string{"kube-system", "istio-system"}},
+			namespace: "default",
+			expected:  true,
+		},
+		{
+			title:     "denied glob",
+			filter:    NamespaceFilter{Deny: []string{"*-system"}},
+			namespace: "istio-system",
+			expected:  false,
+		},
+		{
+			title:     "allowed glob",
+			filter:    NamespaceFilter{Allow: []string{"team-*"}},
+			namespace: "team-a",
+			expected:  true,
+		},
+		{
+			title:     "namespace not allowed",
+			filter:    NamespaceFilter{Allow: []string{"team-*"}},
+			namespace: "default",
+			expected:  false,
+		},
+		{
+			title:     "deny takes precedence over allow",
+			filter:    NamespaceFilter{Allow: []string{"team-*"}, Deny: []string{"team-legacy"}},
+			namespace: "team-legacy",
+			expected:  false,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.Match(tc.namespace))
+		})
+	}
+}
+
+// testNamespaceFilterValidate tests that malformed patterns are rejected.
+func testNamespaceFilterValidate(t *testing.T) {
+	assert.NoError(t, NamespaceFilter{Allow: []string{"team-*"}, Deny: []string{"kube-system"}}.Validate())
+	assert.Error(t, NamespaceFilter{Allow: []string{"team-["}}.Validate())
+	assert.Error(t, NamespaceFilter{Deny: []string{"[-system"}}.Validate())
+}
+
+// testNamespaceFilterEndpoints tests that endpoints of resources in filtered namespaces are dropped.
+func testNamespaceFilterEndpoints(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/default/foo"}},
+		{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.5"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/kube-system/bar"}},
+		{DNSName: "baz.example.org", Targets: endpoint.Targets{"1.2.3.6"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "file/etc/records"}},
+		{DNSName: "qux.example.org", Targets: endpoint.Targets{"1.2.3.7"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "quux.example.org", Targets: endpoint.Targets{"1.2.3.8"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "file/kube-system/records.yaml"}},
+		{DNSName: "corge.example.org", Targets: endpoint.Targets{"1.2.3.9"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "route/kube-system/corge/v1"}},
+	}, nil)
+
+	endpoints, err := NewModifiedSource(mockSource, FilterNamespaces(NamespaceFilter{Deny: []string{"kube-system"}})).Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "baz.example.org", Targets: endpoint.Targets{"1.2.3.6"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "qux.example.org", Targets: endpoint.Targets{"1.2.3.7"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "quux.example.org", Targets: endpoint.Targets{"1.2.3.8"}, RecordType: endpoint.RecordTypeA},
+	})
+	mockSource.AssertExpectations(t)
+}
+
+// testNamespaceFilterFileSource tests that the endpoints of the file source are kept,
+// even though the directory of the file looks like a namespace in their resource label.
+func testNamespaceFilterFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir(".", "kube-system")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "endpoints.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`[{"dnsName": "foo.example.org", "targets": ["1.2.3.4"]}]`), 0644))
+
+	src, err := NewFileSource(path)
+	require.NoError(t, err)
+
+	endpoints, err := NewModifiedSource(src, FilterNamespaces(NamespaceFilter{Deny: []string{"kube-system*"}})).Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+	})
+	assert.Equal(t, "file/"+path, endpoints[0].Labels[endpoint.ResourceLabelKey])
+}
+
+// testNamespaceFilterByNames tests that a Contour source built by name with a namespace filter
+// watches all namespaces and only publishes the resources of allowed namespaces.
+func testNamespaceFilterByNames(t *testing.T) {
+	dynamicClient, scheme := newDynamicKubernetesClient()
+	for _, hp := range []*projectcontour.HTTPProxy{
+		fakeHTTPProxy{
+			namespace:    "team-a",
+			name:         "allowed",
+			host:         "allowed.example.org",
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace:    "kube-system",
+			name:         "denied",
+			host:         "denied.example.org",
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.4.4"}},
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "not-allowed",
+			host:         "not-allowed.example.org",
+			loadBalancer: fakeLoadBalancerService{ips: []string{"1.1.1.1"}},
+		}.HTTPProxy(),
+	} {
+		converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+		require.NoError(t, err)
+		_, err = dynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fake.NewSimpleClientset(), nil)
+	mockClientGenerator.On("DynamicKubernetesClient").Return(dynamicClient, nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"contour-httpproxy"}, &Config{
+		NamespaceFilter: NamespaceFilter{Allow: []string{"team-*", "kube-*"}, Deny: []string{"kube-system"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	endpoints, err := sources[0].Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "allowed.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
@@ -20,7 +20,6 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"strings"
 	"text/template"
 	"time"
 
@@ -28,7 +27,6 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -42,22 +40,21 @@ type nodeSource struct {
 	annotationFilter string
 	fqdnTemplate     *template.Template
 	nodeInformer     coreinformers.NodeInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewNodeSource creates a new nodeSource with the given config.
-func NewNodeSource(kubeClient kubernetes.Interface, annotationFilter, fqdnTemplate string) (Source, error) {
+func NewNodeSource(kubeClient kubernetes.Interface, annotationFilter, fqdnTemplate string, cacheSyncTimeout time.Duration) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
 
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informers to listen for add/update/delete of nodes.
@@ -74,18 +71,20 @@ func NewNodeSource(kubeClient kubernetes.Interface, annotationFilter, fqdnTempla
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return nodeInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("node", "Node", cacheSyncTimeout, nodeInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	return &nodeSource{
+		stopper:          stop,
+		informerHealth:   newInformerHealth("node", nodeInformer.Informer().HasSynced),
 		client:           kubeClient,
 		annotationFilter: annotationFilter,
 		fqdnTemplate:     tmpl,
@@ -95,11 +94,20 @@ func NewNodeSource(kubeClient kubernetes.Interface, annotationFilter, fqdnTempla
 
 // Endpoints returns endpoint objects for each service that should be processed.
 func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("node")
+
 	nodes, err := ns.nodeInformer.Lister().List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
 
+	for i, node := range nodes {
+		if annotations, ok := ns.translateAnnotations(node.Annotations); ok {
+			node = node.DeepCopy()
+			node.Annotations = annotations
+			nodes[i] = node
+		}
+	}
 	nodes, err = ns.filterByAnnotations(nodes)
 	if err != nil {
 		return nil, err
@@ -114,6 +122,7 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping node %s because controller value does not match, found: %s, required: %s",
 				node.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
 			continue
 		}
 
@@ -165,6 +174,7 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 		endpointsSlice = append(endpointsSlice, ep)
 	}
 
+	metrics.publish(endpointsSlice)
 	return endpointsSlice, nil
 }
 
@@ -18,13 +18,18 @@ package source
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -32,6 +37,8 @@ import (
 func TestNodeSource(t *testing.T) {
 	t.Run("NewNodeSource", testNodeSourceNewNodeSource)
 	t.Run("Endpoints", testNodeSourceEndpoints)
+	t.Run("Close", testNodeSourceClose)
+	t.Run("Healthy", testNodeSourceHealthy)
 }
 
 // testNodeSourceNewNodeSource tests that NewNodeService doesn't return an error.
@@ -67,6 +74,7 @@ func testNodeSourceNewNodeSource(t *testing.T) {
 				fake.NewSimpleClientset(),
 				ti.annotationFilter,
 				ti.fqdnTemplate,
+				0,
 			)
 
 			if ti.expectError {
@@ -331,6 +339,7 @@ func testNodeSourceEndpoints(t *testing.T) {
 				kubernetes,
 				tc.annotationFilter,
 				tc.fqdnTemplate,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -346,3 +355,43 @@ func testNodeSourceEndpoints(t *testing.T) {
 		})
 	}
 }
+
+// testNodeSourceClose tests that closing the source stops its informer.
+func testNodeSourceClose(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	watches := make(chan *watch.FakeWatcher, 1)
+	kubeClient.PrependWatchReactor("nodes", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w := watch.NewFake()
+		watches <- w
+		return true, w, nil
+	})
+
+	src, err := NewNodeSource(kubeClient, "", "", 0)
+	require.NoError(t, err)
+
+	var w *watch.FakeWatcher
+	select {
+	case w = <-watches:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("informer did not start watching nodes")
+	}
+	assert.False(t, w.IsStopped())
+
+	closer, ok := src.(io.Closer)
+	require.True(t, ok, "node source should implement io.Closer")
+	require.NoError(t, closer.Close())
+	require.NoError(t, closer.Close(), "closing the source twice should not fail")
+
+	assert.Eventually(t, w.IsStopped, wait.ForeverTestTimeout, 10*time.Millisecond, "informer should stop watching nodes")
+}
+
+// testNodeSourceHealthy tests that the node source is healthy once its informer has synced.
+func testNodeSourceHealthy(t *testing.T) {
+	src, err := NewNodeSource(fake.NewSimpleClientset(), "", "", 0)
+	require.NoError(t, err)
+	defer src.(io.Closer).Close()
+
+	_, ok := src.(HealthChecker)
+	require.True(t, ok, "node source should implement HealthChecker")
+	assert.NoError(t, CheckHealth(src))
+}
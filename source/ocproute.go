@@ -32,14 +32,13 @@ import (
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
 // ocpRouteSource is an implementation of Source for OpenShift Route objects.
-// Route implementation will use the spec.host value for the hostname
+// Route implementation will use the spec.host and status.ingress[].host values for the hostnames
 // Use targetAnnotationKey to explicitly set Endpoint. (useful if the router
 // does not update, or to override with alternative endpoint)
 type ocpRouteSource struct {
@@ -50,6 +49,9 @@ type ocpRouteSource struct {
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
 	routeInformer            routeInformer.RouteInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewOcpRouteSource creates a new ocpRouteSource with the given config.
@@ -60,18 +62,15 @@ func NewOcpRouteSource(
 	fqdnTemplate string,
 	combineFQDNAnnotation bool,
 	ignoreHostnameAnnotation bool,
+	cacheSyncTimeout time.Duration,
 ) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informer to listen for add/update/delete of Routes in the specified namespace.
@@ -87,18 +86,20 @@ func NewOcpRouteSource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return routeInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("openshift-route", "Route", cacheSyncTimeout, routeInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	return &ocpRouteSource{
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("openshift-route", routeInformer.Informer().HasSynced),
 		client:                   ocpClient,
 		namespace:                namespace,
 		annotationFilter:         annotationFilter,
@@ -116,11 +117,20 @@ func (ors *ocpRouteSource) AddEventHandler(ctx context.Context, handler func())
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all OpenShift Route resources on all namespaces
 func (ors *ocpRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("openshift-route")
+
 	ocpRoutes, err := ors.routeInformer.Lister().Routes(ors.namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
 
+	for i, ocpRoute := range ocpRoutes {
+		if annotations, ok := ors.translateAnnotations(ocpRoute.Annotations); ok {
+			ocpRoute = ocpRoute.DeepCopy()
+			ocpRoute.Annotations = annotations
+			ocpRoutes[i] = ocpRoute
+		}
+	}
 	ocpRoutes, err = ors.filterByAnnotations(ocpRoutes)
 	if err != nil {
 		return nil, err
@@ -134,6 +144,12 @@ func (ors *ocpRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping OpenShift Route %s/%s because controller value does not match, found: %s, required: %s",
 				ocpRoute.Namespace, ocpRoute.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(ocpRoute.Annotations); err != nil {
+			log.Warnf("Skipping OpenShift Route %s/%s: %v", ocpRoute.Namespace, ocpRoute.Name, err)
+			metrics.skip(skipReasonInvalid)
 			continue
 		}
 
@@ -155,11 +171,13 @@ func (ors *ocpRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 
 		if len(orEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from OpenShift Route %s/%s", ocpRoute.Namespace, ocpRoute.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from OpenShift Route: %s/%s: %v", ocpRoute.Namespace, ocpRoute.Name, orEndpoints)
 		ors.setResourceLabel(ocpRoute, orEndpoints)
+		setOwnerIDLabel(ocpRoute.Annotations, orEndpoints)
 		endpoints = append(endpoints, orEndpoints...)
 	}
 
@@ -167,6 +185,7 @@ func (ors *ocpRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
@@ -192,13 +211,14 @@ func (ors *ocpRouteSource) endpointsFromTemplate(ocpRoute *routeapi.Route) ([]*e
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ocpRoute.Annotations)
+	recordType := getRecordTypeFromAnnotations(ocpRoute.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	// splits the FQDN template and removes the trailing periods
 	hostnameList := strings.Split(strings.Replace(hostnames, " ", "", -1), ",")
 	for _, hostname := range hostnameList {
 		hostname = strings.TrimSuffix(hostname, ".")
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 	return endpoints, nil
 }
@@ -255,21 +275,43 @@ func endpointsFromOcpRoute(ocpRoute *routeapi.Route, ignoreHostnameAnnotation bo
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ocpRoute.Annotations)
+	recordType := getRecordTypeFromAnnotations(ocpRoute.Annotations)
 
-	if host := ocpRoute.Spec.Host; host != "" {
-		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier)...)
+	for _, host := range hostsFromOcpRoute(ocpRoute) {
+		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	// Skip endpoints if we do not want entries from annotations
 	if !ignoreHostnameAnnotation {
 		hostnameList := getHostnamesFromAnnotations(ocpRoute.Annotations)
 		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 		}
 	}
 	return endpoints
 }
 
+// hostsFromOcpRoute returns the spec.host of the OpenShift Route followed by the hosts
+// admitted by its routers in status.ingress, each of them once.
+func hostsFromOcpRoute(ocpRoute *routeapi.Route) []string {
+	var hosts []string
+	seen := map[string]bool{}
+	add := func(host string) {
+		host = strings.TrimSuffix(host, ".")
+		if host == "" || seen[host] {
+			return
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+
+	add(ocpRoute.Spec.Host)
+	for _, ing := range ocpRoute.Status.Ingress {
+		add(ing.Host)
+	}
+	return hosts
+}
+
 func targetsFromOcpRouteStatus(status routeapi.RouteStatus) endpoint.Targets {
 	var targets endpoint.Targets
 
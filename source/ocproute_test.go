@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	fake "github.com/openshift/client-go/route/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that ocpRouteSource is a Source
+var _ Source = &ocpRouteSource{}
+
+func TestOcpRouteSourceEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title                    string
+		fqdnTemplate             string
+		combineFQDNAnnotation    bool
+		ignoreHostnameAnnotation bool
+		route                    *routev1.Route
+		expected                 []*endpoint.Endpoint
+	}{
+		{
+			title: "spec host with router canonical hostname",
+			route: fakeOcpRoute("route-with-target", "my-domain.com", nil, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title: "status ingress hosts",
+			route: func() *routev1.Route {
+				route := fakeOcpRoute("route-with-ingress-hosts", "my-domain.com", nil, "apps.my-cluster.com")
+				route.Status.Ingress = append(route.Status.Ingress,
+					routev1.RouteIngress{Host: "alias.my-domain.com"},
+					routev1.RouteIngress{Host: "my-domain.com"},
+				)
+				return route
+			}(),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME},
+				{DNSName: "alias.my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title: "target annotation",
+			route: fakeOcpRoute("route-with-target-annotation", "my-domain.com", map[string]string{
+				targetAnnotationKey: "1.2.3.4",
+			}, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "my-domain.com", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "hostname and ttl annotations",
+			route: fakeOcpRoute("route-with-hostname-annotation", "my-domain.com", map[string]string{
+				hostnameAnnotationKey: "other.my-domain.com",
+				ttlAnnotationKey:      "60",
+			}, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60},
+				{DNSName: "other.my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60},
+			},
+		},
+		{
+			title:                    "ignored hostname annotation",
+			ignoreHostnameAnnotation: true,
+			route: fakeOcpRoute("route-with-ignored-hostname-annotation", "my-domain.com", map[string]string{
+				hostnameAnnotationKey: "other.my-domain.com",
+			}, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title: "foreign controller",
+			route: fakeOcpRoute("route-with-foreign-controller", "my-domain.com", map[string]string{
+				controllerAnnotationKey: "other-controller",
+			}, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:        "fqdn template",
+			fqdnTemplate: "{{.Name}}.my-domain.com",
+			route:        fakeOcpRoute("route-without-host", "", nil, "apps.my-cluster.com"),
+			expected: []*endpoint.Endpoint{
+				{DNSName: "route-without-host.my-domain.com", Targets: endpoint.Targets{"apps.my-cluster.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			_, err := client.RouteV1().Routes(tc.route.Namespace).Create(context.Background(), tc.route, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			src, err := NewOcpRouteSource(client, "", "", tc.fqdnTemplate, tc.combineFQDNAnnotation, tc.ignoreHostnameAnnotation, 0)
+			require.NoError(t, err)
+			defer closeSource(src)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+			for _, ep := range endpoints {
+				assert.Equal(t, "route/default/"+tc.route.Name, ep.Labels[endpoint.ResourceLabelKey])
+			}
+		})
+	}
+}
+
+func fakeOcpRoute(name, host string, annotations map[string]string, routerCanonicalHostname string) *routev1.Route {
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: routev1.RouteSpec{Host: host},
+	}
+	if routerCanonicalHostname != "" {
+		route.Status.Ingress = []routev1.RouteIngress{{RouterCanonicalHostname: routerCanonicalHostname}}
+	}
+	return route
+}
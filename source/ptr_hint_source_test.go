@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestPTRHintSource(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+		{DNSName: "a.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "aaaa.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/default/aaaa"}},
+		{DNSName: "cname.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME, Labels: endpoint.NewLabels()},
+	}, nil)
+
+	endpoints, err := NewModifiedSource(mockSource, LabelPTRHints()).Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 3)
+
+	assert.Equal(t, "true", endpoints[0].Labels[endpoint.CreatePTRLabelKey])
+	assert.Equal(t, "true", endpoints[1].Labels[endpoint.CreatePTRLabelKey])
+	assert.Equal(t, "service/default/aaaa", endpoints[1].Labels[endpoint.ResourceLabelKey])
+	assert.NotContains(t, endpoints[2].Labels, endpoint.CreatePTRLabelKey)
+	mockSource.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReconcileTrigger collapses the events of multiple sources into debounced calls of a single handler,
+// so that a burst of events across the sources results in a single reconciliation.
+type ReconcileTrigger struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	trigger func()
+}
+
+// NewReconcileTrigger creates a new ReconcileTrigger calling its handler at most once per interval.
+// A non-positive interval calls the handler on every event.
+func NewReconcileTrigger(interval time.Duration) *ReconcileTrigger {
+	return &ReconcileTrigger{interval: interval}
+}
+
+// SetHandler sets the handler called for the events of the registered sources, replacing any previous one.
+// A pending call is dropped if the context is done.
+func (rt *ReconcileTrigger) SetHandler(ctx context.Context, handler func()) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.trigger = debounce(ctx, rt.interval, handler)
+}
+
+// Register adds the trigger as event handler of the given sources.
+func (rt *ReconcileTrigger) Register(ctx context.Context, sources ...Source) {
+	for _, source := range sources {
+		source.AddEventHandler(ctx, rt.Trigger)
+	}
+}
+
+// Trigger schedules a call of the handler, unless one is pending already.
+// It does nothing if no handler is set.
+func (rt *ReconcileTrigger) Trigger() {
+	rt.mu.Lock()
+	trigger := rt.trigger
+	rt.mu.Unlock()
+	if trigger != nil {
+		trigger()
+	}
+}
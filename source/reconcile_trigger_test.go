@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileTrigger(t *testing.T) {
+	t.Run("Burst", testReconcileTriggerBurst)
+	t.Run("NoHandler", testReconcileTriggerNoHandler)
+}
+
+// testReconcileTriggerBurst tests that a burst of events from two sources calls the handler once.
+func testReconcileTriggerBurst(t *testing.T) {
+	first, second := &handlerCapturingSource{}, &handlerCapturingSource{}
+	var calls int32
+
+	trigger := NewReconcileTrigger(50 * time.Millisecond)
+	trigger.SetHandler(context.Background(), func() {
+		atomic.AddInt32(&calls, 1)
+	})
+	trigger.Register(context.Background(), first, second)
+
+	for i := 0; i < 5; i++ {
+		first.handler()
+		second.handler()
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// A later event of either source calls the handler again.
+	second.handler()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+// testReconcileTriggerNoHandler tests that events are ignored until a handler is set.
+func testReconcileTriggerNoHandler(t *testing.T) {
+	src := &handlerCapturingSource{}
+	trigger := NewReconcileTrigger(0)
+	trigger.Register(context.Background(), src)
+	src.handler()
+
+	var calls int32
+	trigger.SetHandler(context.Background(), func() {
+		atomic.AddInt32(&calls, 1)
+	})
+	src.handler()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// FilterRecordTypes returns an EndpointModifier dropping the endpoints whose record types are not managed.
+// All endpoints are kept if no record types are given.
+func FilterRecordTypes(recordTypes []string) EndpointModifier {
+	managed := make(map[string]bool, len(recordTypes))
+	for _, recordType := range recordTypes {
+		managed[strings.ToUpper(strings.TrimSpace(recordType))] = true
+	}
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		if len(managed) == 0 {
+			return endpoints
+		}
+		result := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			if !managed[ep.RecordType] {
+				log.Debugf("Dropping endpoint %s of resource %q because its record type %s is not managed", ep.DNSName, ep.Labels[endpoint.ResourceLabelKey], ep.RecordType)
+				continue
+			}
+			result = append(result, ep)
+		}
+		return result
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestRecordTypeFilterSource(t *testing.T) {
+	t.Run("Endpoints", testRecordTypeFilterEndpoints)
+	t.Run("ByNames", testRecordTypeFilterByNames)
+}
+
+// testRecordTypeFilterEndpoints tests that endpoints of unmanaged record types are dropped.
+func testRecordTypeFilterEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		recordTypes []string
+		expected    []*endpoint.Endpoint
+	}{
+		{
+			title: "all record types",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+		},
+		{
+			title:       "CNAME only",
+			recordTypes: []string{"cname"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME},
+			},
+		},
+		{
+			title:       "A and AAAA",
+			recordTypes: []string{"A", "AAAA"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			}, nil)
+
+			endpoints, err := NewModifiedSource(mockSource, FilterRecordTypes(tc.recordTypes)).Endpoints(context.Background())
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, tc.expected)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
+
+// testRecordTypeFilterByNames tests that an A-only filter suppresses the CNAME endpoints of a Contour source built by name.
+func testRecordTypeFilterByNames(t *testing.T) {
+	dynamicClient, scheme := newDynamicKubernetesClient()
+	for _, hp := range []*projectcontour.HTTPProxy{
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "ip",
+			host:         "ip.example.org",
+			loadBalancer: fakeLoadBalancerService{ips: []string{"8.8.8.8"}},
+		}.HTTPProxy(),
+		fakeHTTPProxy{
+			namespace:    "default",
+			name:         "hostname",
+			host:         "hostname.example.org",
+			loadBalancer: fakeLoadBalancerService{hostnames: []string{"lb.example.com"}},
+		}.HTTPProxy(),
+	} {
+		converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+		require.NoError(t, err)
+		_, err = dynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fake.NewSimpleClientset(), nil)
+	mockClientGenerator.On("DynamicKubernetesClient").Return(dynamicClient, nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"contour-httpproxy"}, &Config{ManagedRecordTypes: []string{endpoint.RecordTypeA}})
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	endpoints, err := sources[0].Endpoints(context.Background())
+	require.NoError(t, err)
+
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "ip.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+	})
+}
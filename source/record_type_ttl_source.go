@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// OverrideRecordTypeTTLs returns an EndpointModifier overriding the TTL of the endpoints based on their
+// record type. Endpoints of a record type with an override get the overriding TTL. If preferObjectTTL is set,
+// the override only applies to endpoints that don't already have a TTL configured.
+func OverrideRecordTypeTTLs(overrides map[string]endpoint.TTL, preferObjectTTL bool) EndpointModifier {
+	return func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		for _, ep := range endpoints {
+			ttl, ok := overrides[ep.RecordType]
+			if !ok {
+				continue
+			}
+			if preferObjectTTL && ep.RecordTTL.IsConfigured() {
+				continue
+			}
+			ep.RecordTTL = ttl
+		}
+		return endpoints
+	}
+}
+
+// ParseRecordTypeTTLOverrides parses overrides of the form "TYPE=TTL" into a map of record type to TTL.
+func ParseRecordTypeTTLOverrides(overrides []string) (map[string]endpoint.TTL, error) {
+	ttls := make(map[string]endpoint.TTL, len(overrides))
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%q is not a valid record type TTL override, expected TYPE=TTL", override)
+		}
+		ttl, err := parseTTL(parts[1])
+		if err != nil || ttl < ttlMinimum || ttl > ttlMaximum {
+			return nil, fmt.Errorf("%q is not a valid TTL value for record type %s", parts[1], parts[0])
+		}
+		ttls[strings.ToUpper(parts[0])] = endpoint.TTL(ttl)
+	}
+	return ttls, nil
+}
+
+// validateRecordTypeTTLOverrides checks that the overrides are keyed by upper case record types
+// and hold valid TTLs.
+func validateRecordTypeTTLOverrides(overrides map[string]endpoint.TTL) error {
+	for recordType, ttl := range overrides {
+		if recordType == "" || recordType != strings.ToUpper(recordType) {
+			return fmt.Errorf("%q is not a valid record type for a TTL override", recordType)
+		}
+		if ttl < ttlMinimum || ttl > ttlMaximum {
+			return fmt.Errorf("%d is not a valid TTL value for record type %s", ttl, recordType)
+		}
+	}
+	return nil
+}
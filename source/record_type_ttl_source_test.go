@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	contour "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestRecordTypeTTLSource(t *testing.T) {
+	t.Run("Endpoints", testRecordTypeTTLEndpoints)
+	t.Run("Contour", testRecordTypeTTLContourEndpoints)
+}
+
+// testRecordTypeTTLEndpoints tests that TTL overrides are applied to endpoints of the wrapped source.
+func testRecordTypeTTLEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		title           string
+		preferObjectTTL bool
+		endpoints       []*endpoint.Endpoint
+		expected        []*endpoint.Endpoint
+	}{
+		{
+			title: "override applies to matching record type",
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 60},
+			},
+		},
+		{
+			title: "override wins over object TTL",
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 60},
+			},
+		},
+		{
+			title:           "object TTL wins over override when preferred",
+			preferObjectTTL: true,
+			endpoints: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"2001:db8::2"}, RecordType: endpoint.RecordTypeAAAA},
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 300},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"2001:db8::2"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 60},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return(tc.endpoints, nil)
+
+			source := NewModifiedSource(mockSource, OverrideRecordTypeTTLs(map[string]endpoint.TTL{endpoint.RecordTypeAAAA: 60}, tc.preferObjectTTL))
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+
+			validateEndpoints(t, endpoints, tc.expected)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
+
+// testRecordTypeTTLContourEndpoints tests AAAA specific TTL overrides of the Contour sources.
+func testRecordTypeTTLContourEndpoints(t *testing.T) {
+	lb := fakeLoadBalancerService{
+		ips:       []string{"8.8.8.8", "2001:db8::1"},
+		namespace: "heptio-contour",
+		name:      "contour",
+	}
+	annotations := map[string]string{ttlAnnotationKey: "300"}
+	expected := []*endpoint.Endpoint{
+		{DNSName: "example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA, RecordTTL: 300},
+		{DNSName: "example.org", Targets: endpoint.Targets{"2001:db8::1"}, RecordType: endpoint.RecordTypeAAAA, RecordTTL: 60},
+	}
+	overrides := map[string]endpoint.TTL{endpoint.RecordTypeAAAA: 60}
+
+	t.Run("ingressroute", func(t *testing.T) {
+		kubeClient := fakeKube.NewSimpleClientset()
+		_, err := kubeClient.CoreV1().Services(lb.namespace).Create(context.Background(), lb.Service(), metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		dynamicClient, scheme := newDynamicKubernetesClient()
+		ir := fakeIngressRoute{namespace: "default", name: "fake", host: "example.org", annotations: annotations}.IngressRoute()
+		converted, err := convertIngressRouteToUnstructured(ir, scheme)
+		require.NoError(t, err)
+		_, err = dynamicClient.Resource(contour.IngressRouteGVR).Namespace(ir.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		src, err := NewContourIngressRouteSource(dynamicClient, kubeClient, &Config{ContourLoadBalancerService: "heptio-contour/contour"})
+		require.NoError(t, err)
+
+		endpoints, err := NewModifiedSource(src, OverrideRecordTypeTTLs(overrides, false)).Endpoints(context.Background())
+		assert.NoError(t, err)
+		validateEndpoints(t, endpoints, expected)
+	})
+
+	t.Run("httpproxy", func(t *testing.T) {
+		dynamicClient, scheme := newDynamicKubernetesClient()
+		hp := fakeHTTPProxy{namespace: "default", name: "fake", host: "example.org", annotations: annotations, loadBalancer: lb}.HTTPProxy()
+		converted, err := convertHTTPProxyToUnstructured(hp, scheme)
+		require.NoError(t, err)
+		_, err = dynamicClient.Resource(projectcontour.HTTPProxyGVR).Namespace(hp.Namespace).Create(context.Background(), converted, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		src, err := NewContourHTTPProxySource(dynamicClient, fakeKube.NewSimpleClientset(), &Config{})
+		require.NoError(t, err)
+
+		endpoints, err := NewModifiedSource(src, OverrideRecordTypeTTLs(overrides, false)).Endpoints(context.Background())
+		assert.NoError(t, err)
+		validateEndpoints(t, endpoints, expected)
+	})
+}
+
+func TestParseRecordTypeTTLOverrides(t *testing.T) {
+	ttls, err := ParseRecordTypeTTLOverrides([]string{"aaaa=60", "A=5m"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]endpoint.TTL{"AAAA": 60, "A": 300}, ttls)
+
+	for _, invalid := range []string{"AAAA", "=60", "AAAA=foo", "AAAA=-1"} {
+		_, err := ParseRecordTypeTTLOverrides([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -55,6 +55,7 @@ type routeGroupSource struct {
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	annotationPrefixer
 }
 
 // for testing
@@ -190,15 +191,6 @@ func (cli *routeGroupClient) do(req *http.Request) (*http.Response, error) {
 	return cli.client.Do(req)
 }
 
-func parseTemplate(fqdnTemplate string) (tmpl *template.Template, err error) {
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-	}
-	return tmpl, err
-}
-
 // NewRouteGroupSource creates a new routeGroupSource with the given config.
 func NewRouteGroupSource(timeout time.Duration, token, tokenPath, apiServerURL, namespace, annotationFilter, fqdnTemplate, routegroupVersion string, combineFqdnAnnotation, ignoreHostnameAnnotation bool) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
@@ -247,11 +239,16 @@ func (sc *routeGroupSource) AddEventHandler(ctx context.Context, handler func())
 // Retrieves all routeGroup resources on all namespaces.
 // Logic is ported from ingress without fqdnTemplate
 func (sc *routeGroupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("skipper-routegroup")
+
 	rgList, err := sc.cli.getRouteGroupList(sc.apiEndpoint)
 	if err != nil {
 		log.Errorf("Failed to get RouteGroup list: %v", err)
 		return nil, err
 	}
+	for _, rg := range rgList.Items {
+		rg.Metadata.Annotations, _ = sc.translateAnnotations(rg.Metadata.Annotations)
+	}
 	rgList, err = sc.filterByAnnotations(rgList)
 	if err != nil {
 		return nil, err
@@ -264,6 +261,12 @@ func (sc *routeGroupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping routegroup %s/%s because controller value does not match, found: %s, required: %s",
 				rg.Metadata.Namespace, rg.Metadata.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(rg.Metadata.Annotations); err != nil {
+			log.Warnf("Skipping routegroup %s/%s: %v", rg.Metadata.Namespace, rg.Metadata.Name, err)
+			metrics.skip(skipReasonInvalid)
 			continue
 		}
 
@@ -284,12 +287,14 @@ func (sc *routeGroupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint
 
 		if len(eps) == 0 {
 			log.Debugf("No endpoints could be generated from routegroup %s/%s", rg.Metadata.Namespace, rg.Metadata.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from ingress: %s/%s: %v", rg.Metadata.Namespace, rg.Metadata.Name, eps)
 		sc.setRouteGroupResourceLabel(rg, eps)
-		sc.setRouteGroupDualstackLabel(rg, eps)
+		setDualstackLabel(rg.Metadata.Annotations, fmt.Sprintf("routegroup %s/%s", rg.Metadata.Namespace, rg.Metadata.Name), eps)
+		setOwnerIDLabel(rg.Metadata.Annotations, eps)
 		endpoints = append(endpoints, eps...)
 	}
 
@@ -297,6 +302,7 @@ func (sc *routeGroupSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
@@ -320,13 +326,14 @@ func (sc *routeGroupSource) endpointsFromTemplate(rg *routeGroup) ([]*endpoint.E
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(rg.Metadata.Annotations)
+	recordType := getRecordTypeFromAnnotations(rg.Metadata.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	// splits the FQDN template and removes the trailing periods
 	hostnameList := strings.Split(strings.Replace(hostnames, " ", "", -1), ",")
 	for _, hostname := range hostnameList {
 		hostname = strings.TrimSuffix(hostname, ".")
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 	return endpoints, nil
 }
@@ -337,16 +344,6 @@ func (sc *routeGroupSource) setRouteGroupResourceLabel(rg *routeGroup, eps []*en
 	}
 }
 
-func (sc *routeGroupSource) setRouteGroupDualstackLabel(rg *routeGroup, eps []*endpoint.Endpoint) {
-	val, ok := rg.Metadata.Annotations[ALBDualstackAnnotationKey]
-	if ok && val == ALBDualstackAnnotationValue {
-		log.Debugf("Adding dualstack label to routegroup %s/%s.", rg.Metadata.Namespace, rg.Metadata.Name)
-		for _, ep := range eps {
-			ep.Labels[endpoint.DualstackLabelKey] = "true"
-		}
-	}
-}
-
 // annotation logic ported from source/ingress.go without Spec.TLS part, because it'S not supported in RouteGroup
 func (sc *routeGroupSource) endpointsFromRouteGroup(rg *routeGroup) []*endpoint.Endpoint {
 	endpoints := []*endpoint.Endpoint{}
@@ -368,19 +365,20 @@ func (sc *routeGroupSource) endpointsFromRouteGroup(rg *routeGroup) []*endpoint.
 	}
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(rg.Metadata.Annotations)
+	recordType := getRecordTypeFromAnnotations(rg.Metadata.Annotations)
 
 	for _, src := range rg.Spec.Hosts {
 		if src == "" {
 			continue
 		}
-		endpoints = append(endpoints, endpointsForHostname(src, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(src, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	// Skip endpoints if we do not want entries from annotations
 	if !sc.ignoreHostnameAnnotation {
 		hostnameList := getHostnamesFromAnnotations(rg.Metadata.Annotations)
 		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 		}
 	}
 	return endpoints
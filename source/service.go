@@ -29,7 +29,6 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -65,21 +64,20 @@ type serviceSource struct {
 	podInformer                    coreinformers.PodInformer
 	nodeInformer                   coreinformers.NodeInformer
 	serviceTypeFilter              map[string]struct{}
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewServiceSource creates a new serviceSource with the given config.
-func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool) (Source, error) {
+func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool, cacheSyncTimeout time.Duration) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informers to listen for add/update/delete of services/pods/nodes in the specified namespace.
@@ -116,18 +114,30 @@ func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = poll(time.Second, 60*time.Second, func() (bool, error) {
-		return serviceInformer.Informer().HasSynced() &&
-			endpointsInformer.Informer().HasSynced() &&
-			podInformer.Informer().HasSynced() &&
-			nodeInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("service", "Service", cacheSyncTimeout, serviceInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
+	}
+	err = waitForCacheSync("service", "Endpoints", cacheSyncTimeout, endpointsInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
+	}
+	err = waitForCacheSync("service", "Pod", cacheSyncTimeout, podInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
+	}
+	err = waitForCacheSync("service", "Node", cacheSyncTimeout, nodeInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
 	}
 
 	// Transform the slice into a map so it will
@@ -138,6 +148,8 @@ func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 	}
 
 	return &serviceSource{
+		stopper:                        stop,
+		informerHealth:                 newInformerHealth("service", serviceInformer.Informer().HasSynced, endpointsInformer.Informer().HasSynced, podInformer.Informer().HasSynced, nodeInformer.Informer().HasSynced),
 		client:                         kubeClient,
 		namespace:                      namespace,
 		annotationFilter:               annotationFilter,
@@ -158,10 +170,19 @@ func NewServiceSource(kubeClient kubernetes.Interface, namespace, annotationFilt
 
 // Endpoints returns endpoint objects for each service that should be processed.
 func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("service")
+
 	services, err := sc.serviceInformer.Lister().Services(sc.namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
+	for i, svc := range services {
+		if annotations, ok := sc.translateAnnotations(svc.Annotations); ok {
+			svc = svc.DeepCopy()
+			svc.Annotations = annotations
+			services[i] = svc
+		}
+	}
 	services, err = sc.filterByAnnotations(services)
 	if err != nil {
 		return nil, err
@@ -180,6 +201,7 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping service %s/%s because controller value does not match, found: %s, required: %s",
 				svc.Namespace, svc.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
 			continue
 		}
 
@@ -206,11 +228,13 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		if len(svcEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from service %s/%s", svc.Namespace, svc.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from service: %s/%s: %v", svc.Namespace, svc.Name, svcEndpoints)
 		sc.setResourceLabel(svc, svcEndpoints)
+		setOwnerIDLabel(svc.Annotations, svcEndpoints)
 		endpoints = append(endpoints, svcEndpoints...)
 	}
 
@@ -247,6 +271,7 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
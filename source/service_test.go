@@ -56,6 +56,7 @@ func (suite *ServiceSuite) SetupTest() {
 		false,
 		[]string{},
 		false,
+		0,
 	)
 	suite.fooWithTargets = &v1.Service{
 		Spec: v1.ServiceSpec{
@@ -150,6 +151,7 @@ func testServiceSourceNewServiceSource(t *testing.T) {
 				false,
 				ti.serviceTypesFilter,
 				false,
+				0,
 			)
 
 			if ti.expectError {
@@ -1230,6 +1232,7 @@ func testServiceSourceEndpoints(t *testing.T) {
 				false,
 				tc.serviceTypesFilter,
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -1402,6 +1405,7 @@ func testMultipleServicesEndpoints(t *testing.T) {
 				false,
 				tc.serviceTypesFilter,
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -1586,6 +1590,7 @@ func TestClusterIpServices(t *testing.T) {
 				false,
 				[]string{},
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -2058,6 +2063,7 @@ func TestNodePortServices(t *testing.T) {
 				false,
 				[]string{},
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -2387,6 +2393,7 @@ func TestHeadlessServices(t *testing.T) {
 				false,
 				[]string{},
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -2689,6 +2696,7 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 				false,
 				[]string{},
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -2794,6 +2802,7 @@ func TestExternalServices(t *testing.T) {
 				false,
 				[]string{},
 				tc.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -2834,7 +2843,7 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 	_, err := kubernetes.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
 	require.NoError(b, err)
 
-	client, err := NewServiceSource(kubernetes, v1.NamespaceAll, "", "", false, "", false, false, false, []string{}, false)
+	client, err := NewServiceSource(kubernetes, v1.NamespaceAll, "", "", false, "", false, false, false, []string{}, false, 0)
 	require.NoError(b, err)
 
 	for i := 0; i < b.N; i++ {
@@ -19,15 +19,24 @@ package source
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/config"
@@ -44,6 +53,10 @@ const (
 	targetAnnotationKey = "external-dns.alpha.kubernetes.io/target"
 	// The annotation used for defining the desired DNS record TTL
 	ttlAnnotationKey = "external-dns.alpha.kubernetes.io/ttl"
+	// The annotation used for overriding the record type inferred from the targets
+	recordTypeAnnotationKey = "external-dns.alpha.kubernetes.io/record-type"
+	// The annotation used for suggesting the owner of the records of a resource to the registry
+	ownerIDAnnotationKey = "external-dns.alpha.kubernetes.io/owner-id"
 	// The annotation used for switching to the alias record types e. g. AWS Alias records instead of a normal CNAME
 	aliasAnnotationKey = "external-dns.alpha.kubernetes.io/alias"
 	// The value of the controller annotation so that we feel responsible
@@ -60,18 +73,114 @@ const (
 	SetIdentifierKey = "external-dns.alpha.kubernetes.io/set-identifier"
 )
 
+// DefaultAnnotationPrefix is the prefix of the keys of the annotations read by the sources,
+// unless another one is configured.
+const DefaultAnnotationPrefix = "external-dns.alpha.kubernetes.io/"
+
+// parseAnnotationPrefix returns the given annotation prefix with a trailing slash,
+// or the default prefix if it is empty.
+func parseAnnotationPrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return DefaultAnnotationPrefix, nil
+	}
+	trimmed := strings.TrimSuffix(prefix, "/")
+	if trimmed == "" || strings.ContainsAny(trimmed, "/ ") {
+		return "", fmt.Errorf("invalid annotation prefix %q", prefix)
+	}
+	return trimmed + "/", nil
+}
+
+// annotationPrefixSetter is implemented by sources which read annotations with a configurable prefix.
+type annotationPrefixSetter interface {
+	setAnnotationPrefix(prefix string) error
+}
+
+// annotationPrefixer holds the prefix of the keys of the annotations read by a source.
+// Sources embed it and translate the annotations of each resource once, before reading them.
+type annotationPrefixer struct {
+	annotationPrefix string
+}
+
+// setAnnotationPrefix sets the prefix of the keys of the annotations read by the source.
+// An empty prefix restores the default one.
+func (p *annotationPrefixer) setAnnotationPrefix(prefix string) error {
+	prefix, err := parseAnnotationPrefix(prefix)
+	if err != nil {
+		return err
+	}
+	p.annotationPrefix = prefix
+	return nil
+}
+
+// translateAnnotations returns the annotations with the keys of a custom prefix rewritten to the
+// default prefix, which the annotation helpers look up, and true. The annotations of the default
+// prefix are dropped, so that they are left to other deployments. Without a custom prefix, the
+// annotations are returned as they are along with false.
+func (p *annotationPrefixer) translateAnnotations(annotations map[string]string) (map[string]string, bool) {
+	if p.annotationPrefix == "" || p.annotationPrefix == DefaultAnnotationPrefix {
+		return annotations, false
+	}
+
+	result := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if strings.HasPrefix(k, DefaultAnnotationPrefix) {
+			continue
+		}
+		if strings.HasPrefix(k, p.annotationPrefix) {
+			k = DefaultAnnotationPrefix + strings.TrimPrefix(k, p.annotationPrefix)
+		}
+		result[k] = v
+	}
+	return result, true
+}
+
 const (
 	ttlMinimum = 1
 	ttlMaximum = math.MaxInt32
 )
 
+// defaultCacheSyncTimeout is how long sources wait for their informer caches to sync by default.
+const defaultCacheSyncTimeout = 60 * time.Second
+
 // Source defines the interface Endpoint sources should implement.
+// Sources which start informers additionally implement io.Closer to stop them.
 type Source interface {
 	Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error)
 	// AddEventHandler adds an event handler that should be triggered if something in source changes
 	AddEventHandler(context.Context, func())
 }
 
+// fqdnTemplateFuncs are the functions available to FQDN templates. The set is kept small on purpose:
+//
+//	lower s, upper s:  change the case of s, e.g. {{ lower .Name }}
+//	trimPrefix s p:    removes the prefix p from s, e.g. {{ trimPrefix .Name "app-" }}
+//	trimSuffix s p:    removes the suffix p from s, e.g. {{ trimSuffix .Name "-svc" }}
+//	replace old new s: replaces all occurrences of old in s, e.g. {{ .Name | replace "_" "-" }}
+//	default d s:       returns d if s is empty, e.g. {{ .Namespace | default "default" }}
+var fqdnTemplateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace": func(old, new, s string) string {
+		return strings.Replace(s, old, new, -1)
+	},
+	"default": func(d, s string) string {
+		if s == "" {
+			return d
+		}
+		return s
+	},
+}
+
+// parseTemplate parses the FQDN template, if any, with the fqdnTemplateFuncs.
+func parseTemplate(fqdnTemplate string) (tmpl *template.Template, err error) {
+	if fqdnTemplate != "" {
+		tmpl, err = template.New("endpoint").Funcs(fqdnTemplateFuncs).Parse(fqdnTemplate)
+	}
+	return tmpl, err
+}
+
 func getTTLFromAnnotations(annotations map[string]string) (endpoint.TTL, error) {
 	ttlNotConfigured := endpoint.TTL(0)
 	ttlAnnotation, exists := annotations[ttlAnnotationKey]
@@ -91,10 +200,12 @@ func getTTLFromAnnotations(annotations map[string]string) (endpoint.TTL, error)
 // parseTTL parses TTL from string, returning duration in seconds.
 // parseTTL supports both integers like "600" and durations based
 // on Go Duration like "10m", hence "600" and "10m" represent the same value.
+// Surrounding whitespace is ignored.
 //
 // Note: for durations like "1.5s" the fraction is omitted (resulting in 1 second
 // for the example).
 func parseTTL(s string) (ttlSeconds int64, err error) {
+	s = strings.TrimSpace(s)
 	ttlDuration, err := time.ParseDuration(s)
 	if err != nil {
 		return strconv.ParseInt(s, 10, 64)
@@ -103,6 +214,15 @@ func parseTTL(s string) (ttlSeconds int64, err error) {
 	return int64(ttlDuration.Seconds()), nil
 }
 
+// ensureAnnotations returns the given annotations, or an empty map if they are nil,
+// so that resources created without any annotations are handled like all others.
+func ensureAnnotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return map[string]string{}
+	}
+	return annotations
+}
+
 func getHostnamesFromAnnotations(annotations map[string]string) []string {
 	hostnameAnnotation, exists := annotations[hostnameAnnotationKey]
 	if !exists {
@@ -128,6 +248,22 @@ func getAliasFromAnnotations(annotations map[string]string) bool {
 	return exists && aliasAnnotation == "true"
 }
 
+// getRecordTypeFromAnnotations returns the record type requested by the record type annotation:
+// A, AAAA or CNAME. It returns an empty string if there is no such annotation or its value is not supported.
+func getRecordTypeFromAnnotations(annotations map[string]string) string {
+	recordType, exists := annotations[recordTypeAnnotationKey]
+	if !exists {
+		return ""
+	}
+	switch recordType = strings.ToUpper(strings.TrimSpace(recordType)); recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME:
+		return recordType
+	default:
+		log.Warnf("Ignoring unsupported record type %q of annotation %s", annotations[recordTypeAnnotationKey], recordTypeAnnotationKey)
+		return ""
+	}
+}
+
 func getProviderSpecificAnnotations(annotations map[string]string) (endpoint.ProviderSpecific, string) {
 	providerSpecificAnnotations := endpoint.ProviderSpecific{}
 
@@ -165,6 +301,32 @@ func getProviderSpecificAnnotations(annotations map[string]string) (endpoint.Pro
 	return providerSpecificAnnotations, setIdentifier
 }
 
+// routingPolicyProperties are the provider specific properties of the AWS routing policies.
+// The records of a name with a routing policy are told apart by their set identifiers.
+var routingPolicyProperties = map[string]bool{
+	"aws/weight":                       true,
+	"aws/region":                       true,
+	"aws/failover":                     true,
+	"aws/geolocation-continent-code":   true,
+	"aws/geolocation-country-code":     true,
+	"aws/geolocation-subdivision-code": true,
+	"aws/multi-value-answer":           true,
+}
+
+// checkSetIdentifier returns an error if the provider specific properties declare a routing policy
+// without a set identifier, which the provider would not be able to apply.
+func checkSetIdentifier(providerSpecific endpoint.ProviderSpecific, setIdentifier string) error {
+	if setIdentifier != "" {
+		return nil
+	}
+	for _, property := range providerSpecific {
+		if routingPolicyProperties[property.Name] {
+			return fmt.Errorf("routing policy property %s requires the %s annotation", property.Name, SetIdentifierKey)
+		}
+	}
+	return nil
+}
+
 // getTargetsFromTargetAnnotation gets endpoints from optional "target" annotation.
 // Returns empty endpoints array if none are found.
 func getTargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targets {
@@ -176,6 +338,9 @@ func getTargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targ
 		// splits the hostname annotation and removes the trailing periods
 		targetsList := strings.Split(strings.Replace(targetAnnotation, " ", "", -1), ",")
 		for _, targetHostname := range targetsList {
+			if targetHostname == "" {
+				continue
+			}
 			targetHostname = strings.TrimSuffix(targetHostname, ".")
 			targets = append(targets, targetHostname)
 		}
@@ -183,6 +348,28 @@ func getTargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targ
 	return targets
 }
 
+// checkTargetAnnotation returns an error if the optional "target" annotation mixes IP addresses
+// and hostnames, which can't be published together as the targets of a single record.
+func checkTargetAnnotation(annotations map[string]string) error {
+	return checkTargets(getTargetsFromTargetAnnotation(annotations))
+}
+
+// checkTargets returns an error if the targets of the "target" annotation mix IP addresses and hostnames.
+func checkTargets(targets endpoint.Targets) error {
+	var ips, hostnames []string
+	for _, target := range targets {
+		if net.ParseIP(target) != nil {
+			ips = append(ips, target)
+		} else {
+			hostnames = append(hostnames, target)
+		}
+	}
+	if len(ips) > 0 && len(hostnames) > 0 {
+		return fmt.Errorf("%s annotation mixes IP addresses %v and hostnames %v", targetAnnotationKey, ips, hostnames)
+	}
+	return nil
+}
+
 // suitableType returns the DNS resource record type suitable for the target.
 // In this case type A for IPs and type CNAME for everything else.
 func suitableType(target string) string {
@@ -192,21 +379,61 @@ func suitableType(target string) string {
 	return endpoint.RecordTypeCNAME
 }
 
+// isIPv6String returns whether the target is an IPv6 address.
+func isIPv6String(target string) bool {
+	ip := net.ParseIP(target)
+	return ip != nil && ip.To4() == nil
+}
+
+// suitableTypeWithIPv6 is like suitableType, but returns AAAA for IPv6 addresses.
+func suitableTypeWithIPv6(target string) string {
+	if isIPv6String(target) {
+		return endpoint.RecordTypeAAAA
+	}
+	return suitableType(target)
+}
+
 // endpointsForHostname returns the endpoint objects for each host-target combination.
-func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string) []*endpoint.Endpoint {
+// Duplicate targets are only included once. The endpoints are returned in the order A, AAAA, CNAME
+// with sorted targets, so the result doesn't depend on the order of the given targets.
+// The record type of each target is inferred from it, unless recordType overrides it: all targets
+// of a CNAME are used as is, while an A or AAAA only gets the addresses of its family and the other
+// targets are skipped.
+func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, recordType string) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
 
 	var aTargets endpoint.Targets
+	var aaaaTargets endpoint.Targets
 	var cnameTargets endpoint.Targets
 
+	seen := map[string]bool{}
 	for _, t := range targets {
-		switch suitableType(t) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		targetType := suitableTypeWithIPv6(t)
+		switch {
+		case recordType == endpoint.RecordTypeCNAME:
+			targetType = endpoint.RecordTypeCNAME
+		case recordType != "" && recordType != targetType:
+			log.Warnf("Skipping target %s of %s: it is not compatible with the requested record type %s", t, hostname, recordType)
+			continue
+		}
+
+		switch targetType {
 		case endpoint.RecordTypeA:
 			aTargets = append(aTargets, t)
+		case endpoint.RecordTypeAAAA:
+			aaaaTargets = append(aaaaTargets, t)
 		default:
 			cnameTargets = append(cnameTargets, t)
 		}
 	}
+	sort.Sort(aTargets)
+	sort.Sort(aaaaTargets)
+	sort.Sort(cnameTargets)
 
 	if len(aTargets) > 0 {
 		epA := &endpoint.Endpoint{
@@ -221,6 +448,19 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 		endpoints = append(endpoints, epA)
 	}
 
+	if len(aaaaTargets) > 0 {
+		epAAAA := &endpoint.Endpoint{
+			DNSName:          strings.TrimSuffix(hostname, "."),
+			Targets:          aaaaTargets,
+			RecordTTL:        ttl,
+			RecordType:       endpoint.RecordTypeAAAA,
+			Labels:           endpoint.NewLabels(),
+			ProviderSpecific: providerSpecific,
+			SetIdentifier:    setIdentifier,
+		}
+		endpoints = append(endpoints, epAAAA)
+	}
+
 	if len(cnameTargets) > 0 {
 		epCNAME := &endpoint.Endpoint{
 			DNSName:          strings.TrimSuffix(hostname, "."),
@@ -250,6 +490,175 @@ func matchLabelSelector(selector labels.Selector, srcAnnotations map[string]stri
 	return selector.Matches(annotations)
 }
 
+// stopper stops the informers of a source once it is closed.
+type stopper struct {
+	once   sync.Once
+	stopCh chan struct{}
+}
+
+func newStopper() *stopper {
+	return &stopper{stopCh: make(chan struct{})}
+}
+
+// Close stops the informers of the source. It is safe to call it multiple times.
+func (s *stopper) Close() error {
+	s.once.Do(func() {
+		close(s.stopCh)
+	})
+	return nil
+}
+
+// HealthChecker is implemented by sources which can report whether they are healthy,
+// e.g. whether the caches of their informers are populated.
+type HealthChecker interface {
+	Healthy() error
+}
+
+// CheckHealth returns an error if the source implements HealthChecker and is unhealthy.
+// Sources which can't report their health are considered healthy.
+func CheckHealth(source Source) error {
+	if checker, ok := source.(HealthChecker); ok {
+		return checker.Healthy()
+	}
+	return nil
+}
+
+// Warning describes a resource which a source skipped.
+type Warning struct {
+	// Resource identifies the resource, e.g. "HTTPProxy/default/foo".
+	Resource string
+	// Reason is why the resource was skipped: "controller", "invalid", "no-annotation" or "no-endpoints".
+	Reason string
+	// Message is a human readable description of the problem.
+	Message string
+}
+
+// WarningReporter is implemented by sources which can report the resources they skip.
+type WarningReporter interface {
+	EndpointsWithWarnings(ctx context.Context) ([]*endpoint.Endpoint, []Warning, error)
+}
+
+// EndpointsWithWarnings returns the endpoints of the source along with the warnings it reports
+// if it implements WarningReporter. Other sources report no warnings.
+func EndpointsWithWarnings(ctx context.Context, source Source) ([]*endpoint.Endpoint, []Warning, error) {
+	if reporter, ok := source.(WarningReporter); ok {
+		return reporter.EndpointsWithWarnings(ctx)
+	}
+	endpoints, err := source.Endpoints(ctx)
+	return endpoints, nil, err
+}
+
+// informerHealth reports a source as healthy once the caches of all of its informers have synced.
+type informerHealth struct {
+	source    string
+	hasSynced []cache.InformerSynced
+}
+
+func newInformerHealth(source string, hasSynced ...cache.InformerSynced) *informerHealth {
+	return &informerHealth{source: source, hasSynced: hasSynced}
+}
+
+// Healthy returns an error unless the caches of all informers of the source have synced.
+func (h *informerHealth) Healthy() error {
+	for _, synced := range h.hasSynced {
+		if !synced() {
+			return fmt.Errorf("informer caches of %s source have not synced", h.source)
+		}
+	}
+	return nil
+}
+
+// closeSource closes the source if it implements io.Closer.
+func closeSource(source Source) error {
+	if closer, ok := source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// waitForCacheSync waits for the cache of an informer of the named source to be populated.
+// It gives up once the timeout, or defaultCacheSyncTimeout if none is given, is exceeded.
+func waitForCacheSync(source, informerType string, timeout time.Duration, hasSynced cache.InformerSynced) error {
+	if timeout <= 0 {
+		timeout = defaultCacheSyncTimeout
+	}
+	err := poll(time.Second, timeout, func() (bool, error) {
+		return hasSynced(), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync %s cache of %s source within %s: %v", informerType, source, timeout, err)
+	}
+	return nil
+}
+
+// setDualstackLabel labels the endpoints generated from the described resource as dualstack
+// if its annotations mark it as an ALB dualstack resource.
+func setDualstackLabel(annotations map[string]string, resource string, endpoints []*endpoint.Endpoint) {
+	val, ok := annotations[ALBDualstackAnnotationKey]
+	if ok && val == ALBDualstackAnnotationValue {
+		log.Debugf("Adding dualstack label to %s.", resource)
+		for _, ep := range endpoints {
+			ep.Labels[endpoint.DualstackLabelKey] = "true"
+		}
+	}
+}
+
+// setOwnerIDLabel labels the endpoints generated from a resource with the owner its annotations suggest, if any.
+func setOwnerIDLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	ownerID := strings.TrimSpace(annotations[ownerIDAnnotationKey])
+	if ownerID == "" {
+		return
+	}
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		ep.Labels[endpoint.OwnerIDLabelKey] = ownerID
+	}
+}
+
+// getServiceWithRetries gets the named service. Transient errors are retried up to
+// retries times with exponential backoff, while errors such as NotFound are returned
+// immediately.
+func getServiceWithRetries(ctx context.Context, client kubernetes.Interface, namespace, name string, retries int) (svc *v1.Service, err error) {
+	if retries < 0 {
+		retries = 0
+	}
+	backoff := wait.Backoff{Duration: time.Second, Factor: 2, Jitter: 0.1, Steps: retries + 1}
+	if config.FastPoll {
+		backoff.Duration = time.Millisecond
+	}
+
+	retryErr := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		svc, err = client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return true, nil
+		}
+		if !isRetryableLookupError(err) || ctx.Err() != nil {
+			return false, err
+		}
+		log.Debugf("Failed to get service %s/%s, retrying: %v", namespace, name, err)
+		return false, nil
+	})
+	if retryErr == wait.ErrWaitTimeout {
+		// Return the error of the last attempt rather than the timeout.
+		return nil, err
+	}
+	if retryErr != nil {
+		return nil, retryErr
+	}
+	return svc, nil
+}
+
+// isRetryableLookupError returns whether the error of a lookup may go away by itself.
+func isRetryableLookupError(err error) bool {
+	return !apierrors.IsNotFound(err) &&
+		!apierrors.IsForbidden(err) &&
+		!apierrors.IsUnauthorized(err) &&
+		!apierrors.IsBadRequest(err) &&
+		!apierrors.IsInvalid(err)
+}
+
 func poll(interval time.Duration, timeout time.Duration, condition wait.ConditionFunc) error {
 	if config.FastPoll {
 		time.Sleep(5 * time.Millisecond)
@@ -265,7 +674,9 @@ func poll(interval time.Duration, timeout time.Duration, condition wait.Conditio
 		}
 
 		interval = 50 * time.Millisecond
-		timeout = 10 * time.Second
+		if timeout > 10*time.Second {
+			timeout = 10 * time.Second
+		}
 	}
 
 	return wait.Poll(interval, timeout, condition)
@@ -17,12 +17,24 @@ limitations under the License.
 package source
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
 )
 
 func TestGetTTLFromAnnotations(t *testing.T) {
@@ -56,6 +68,18 @@ func TestGetTTLFromAnnotations(t *testing.T) {
 			expectedTTL: endpoint.TTL(0),
 			expectedErr: fmt.Errorf("TTL value must be between [%d, %d]", ttlMinimum, ttlMaximum),
 		},
+		{
+			title:       "TTL annotation value is negative duration",
+			annotations: map[string]string{ttlAnnotationKey: "-1m"},
+			expectedTTL: endpoint.TTL(0),
+			expectedErr: fmt.Errorf("TTL value must be between [%d, %d]", ttlMinimum, ttlMaximum),
+		},
+		{
+			title:       "TTL annotation value is not a duration",
+			annotations: map[string]string{ttlAnnotationKey: "abc"},
+			expectedTTL: endpoint.TTL(0),
+			expectedErr: fmt.Errorf("\"abc\" is not a valid TTL value"),
+		},
 		{
 			title:       "TTL annotation value is too high",
 			annotations: map[string]string{ttlAnnotationKey: fmt.Sprintf("%d", 1<<32)},
@@ -68,6 +92,24 @@ func TestGetTTLFromAnnotations(t *testing.T) {
 			expectedTTL: endpoint.TTL(60),
 			expectedErr: nil,
 		},
+		{
+			title:       "TTL annotation value is set correctly using integer with whitespace",
+			annotations: map[string]string{ttlAnnotationKey: " 60 "},
+			expectedTTL: endpoint.TTL(60),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is set correctly using duration (minutes)",
+			annotations: map[string]string{ttlAnnotationKey: "1m"},
+			expectedTTL: endpoint.TTL(60),
+			expectedErr: nil,
+		},
+		{
+			title:       "TTL annotation value is set correctly using duration (compound)",
+			annotations: map[string]string{ttlAnnotationKey: "1h30m"},
+			expectedTTL: endpoint.TTL(5400),
+			expectedErr: nil,
+		},
 		{
 			title:       "TTL annotation value is set correctly using duration (whole)",
 			annotations: map[string]string{ttlAnnotationKey: "10m"},
@@ -105,3 +147,377 @@ func TestSuitableType(t *testing.T) {
 		}
 	}
 }
+
+func TestGetTargetsFromTargetAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expected    endpoint.Targets
+	}{
+		{
+			title:       "no annotation",
+			annotations: map[string]string{},
+		},
+		{
+			title:       "targets",
+			annotations: map[string]string{targetAnnotationKey: "1.2.3.4,lb.example.org."},
+			expected:    endpoint.Targets{"1.2.3.4", "lb.example.org"},
+		},
+		{
+			title:       "empty targets are ignored",
+			annotations: map[string]string{targetAnnotationKey: " 1.2.3.4 , ,5.6.7.8,"},
+			expected:    endpoint.Targets{"1.2.3.4", "5.6.7.8"},
+		},
+		{
+			title:       "weights are not parsed",
+			annotations: map[string]string{targetAnnotationKey: "1.2.3.4=70"},
+			expected:    endpoint.Targets{"1.2.3.4=70"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getTargetsFromTargetAnnotation(tc.annotations))
+		})
+	}
+}
+
+func TestCheckTargetAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expectError bool
+	}{
+		{
+			title:       "no annotation",
+			annotations: map[string]string{},
+		},
+		{
+			title:       "IPv4 addresses",
+			annotations: map[string]string{targetAnnotationKey: "1.2.3.4,5.6.7.8"},
+		},
+		{
+			title:       "IPv4 and IPv6 addresses",
+			annotations: map[string]string{targetAnnotationKey: "1.2.3.4,2001:db8::1"},
+		},
+		{
+			title:       "hostnames",
+			annotations: map[string]string{targetAnnotationKey: "lb1.example.org,lb2.example.org"},
+		},
+		{
+			title:       "IP addresses and hostnames",
+			annotations: map[string]string{targetAnnotationKey: "1.2.3.4,lb.example.org"},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := checkTargetAnnotation(tc.annotations)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEndpointsForHostnameDeduplicatesTargets(t *testing.T) {
+	endpoints := endpointsForHostname(
+		"example.org",
+		endpoint.Targets{"1.2.3.4", "5.6.7.8", "1.2.3.4", "lb.example.org", "lb.example.org"},
+		endpoint.TTL(0),
+		endpoint.ProviderSpecific{},
+		"",
+		"",
+	)
+
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, endpoint.RecordTypeA, endpoints[0].RecordType)
+	assert.Equal(t, endpoint.Targets{"1.2.3.4", "5.6.7.8"}, endpoints[0].Targets)
+	assert.Equal(t, endpoint.RecordTypeCNAME, endpoints[1].RecordType)
+	assert.Equal(t, endpoint.Targets{"lb.example.org"}, endpoints[1].Targets)
+}
+
+func TestEndpointsForHostnameIsStable(t *testing.T) {
+	targets := endpoint.Targets{"lb-b.example.org", "5.6.7.8", "2001:db8::2", "1.2.3.4", "lb-a.example.org", "2001:db8::1"}
+	expected := []*endpoint.Endpoint{
+		{DNSName: "example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4", "5.6.7.8"}, Labels: endpoint.NewLabels(), ProviderSpecific: endpoint.ProviderSpecific{}},
+		{DNSName: "example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1", "2001:db8::2"}, Labels: endpoint.NewLabels(), ProviderSpecific: endpoint.ProviderSpecific{}},
+		{DNSName: "example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb-a.example.org", "lb-b.example.org"}, Labels: endpoint.NewLabels(), ProviderSpecific: endpoint.ProviderSpecific{}},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		shuffled := append(endpoint.Targets{}, targets...)
+		r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		endpoints := endpointsForHostname("example.org", shuffled, endpoint.TTL(0), endpoint.ProviderSpecific{}, "", "")
+		assert.Equal(t, expected, endpoints, "targets %v", shuffled)
+	}
+}
+
+func TestGetRecordTypeFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expected    string
+	}{
+		{"no annotation", map[string]string{}, ""},
+		{"A", map[string]string{recordTypeAnnotationKey: "A"}, endpoint.RecordTypeA},
+		{"lower case AAAA", map[string]string{recordTypeAnnotationKey: "aaaa"}, endpoint.RecordTypeAAAA},
+		{"CNAME with spaces", map[string]string{recordTypeAnnotationKey: " CNAME "}, endpoint.RecordTypeCNAME},
+		{"unsupported", map[string]string{recordTypeAnnotationKey: "TXT"}, ""},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getRecordTypeFromAnnotations(tc.annotations))
+		})
+	}
+}
+
+func TestEndpointsForHostnameRecordType(t *testing.T) {
+	targets := endpoint.Targets{"1.2.3.4", "2001:db8::1", "lb.example.org"}
+
+	t.Run("CNAME", func(t *testing.T) {
+		endpoints := endpointsForHostname("example.org", targets, endpoint.TTL(0), endpoint.ProviderSpecific{}, "", endpoint.RecordTypeCNAME)
+		assert.Len(t, endpoints, 1)
+		assert.Equal(t, endpoint.RecordTypeCNAME, endpoints[0].RecordType)
+		assert.Equal(t, endpoint.Targets{"1.2.3.4", "2001:db8::1", "lb.example.org"}, endpoints[0].Targets)
+	})
+
+	t.Run("A", func(t *testing.T) {
+		endpoints := endpointsForHostname("example.org", targets, endpoint.TTL(0), endpoint.ProviderSpecific{}, "", endpoint.RecordTypeA)
+		assert.Len(t, endpoints, 1)
+		assert.Equal(t, endpoint.RecordTypeA, endpoints[0].RecordType)
+		assert.Equal(t, endpoint.Targets{"1.2.3.4"}, endpoints[0].Targets)
+	})
+
+	t.Run("A without compatible targets", func(t *testing.T) {
+		endpoints := endpointsForHostname("example.org", endpoint.Targets{"lb.example.org"}, endpoint.TTL(0), endpoint.ProviderSpecific{}, "", endpoint.RecordTypeA)
+		assert.Empty(t, endpoints)
+	})
+}
+
+func TestSetOwnerIDLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		{DNSName: "a.example.org"},
+		{DNSName: "b.example.org", Labels: endpoint.Labels{endpoint.ResourceLabelKey: "ingress/default/b"}},
+	}
+
+	setOwnerIDLabel(map[string]string{}, endpoints)
+	assert.Empty(t, endpoints[0].Labels[endpoint.OwnerIDLabelKey])
+
+	setOwnerIDLabel(map[string]string{ownerIDAnnotationKey: " team-a "}, endpoints)
+	for _, ep := range endpoints {
+		assert.Equal(t, "team-a", ep.Labels[endpoint.OwnerIDLabelKey])
+	}
+	assert.Equal(t, "ingress/default/b", endpoints[1].Labels[endpoint.ResourceLabelKey])
+}
+
+func TestParseAnnotationPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		prefix      string
+		expected    string
+		expectError bool
+	}{
+		{prefix: "", expected: DefaultAnnotationPrefix},
+		{prefix: "dns.example.com/", expected: "dns.example.com/"},
+		{prefix: "dns.example.com", expected: "dns.example.com/"},
+		{prefix: "/", expectError: true},
+		{prefix: "dns.example.com/sub/", expectError: true},
+	} {
+		t.Run(tc.prefix, func(t *testing.T) {
+			prefix, err := parseAnnotationPrefix(tc.prefix)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, prefix)
+		})
+	}
+}
+
+func TestCustomAnnotationPrefix(t *testing.T) {
+	p := annotationPrefixer{}
+	require.NoError(t, p.setAnnotationPrefix("dns.example.com"))
+
+	annotations, ok := p.translateAnnotations(map[string]string{
+		"dns.example.com/hostname":                            "foo.example.org, bar.example.org",
+		"dns.example.com/target":                              "1.2.3.4",
+		"dns.example.com/ttl":                                 "60",
+		"dns.example.com/set-identifier":                      "eu",
+		"dns.example.com/aws-geolocation-country-code":        "DE",
+		"external-dns.alpha.kubernetes.io/hostname":           "other.example.org",
+		"external-dns.alpha.kubernetes.io/aws-weight":         "10",
+		"external-dns.alpha.kubernetes.io/cloudflare-proxied": "true",
+	})
+	require.True(t, ok)
+
+	assert.Equal(t, []string{"foo.example.org", "bar.example.org"}, getHostnamesFromAnnotations(annotations))
+	assert.Equal(t, endpoint.Targets{"1.2.3.4"}, getTargetsFromTargetAnnotation(annotations))
+	ttl, err := getTTLFromAnnotations(annotations)
+	require.NoError(t, err)
+	assert.Equal(t, endpoint.TTL(60), ttl)
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(annotations)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: "aws/geolocation-country-code", Value: "DE"}}, providerSpecific)
+	assert.Equal(t, "eu", setIdentifier)
+
+	// The annotations of the default prefix are ignored.
+	annotations, _ = p.translateAnnotations(map[string]string{hostnameAnnotationKey: "foo.example.org"})
+	assert.Nil(t, getHostnamesFromAnnotations(annotations))
+
+	// Without a custom prefix, the annotations are left untouched.
+	p = annotationPrefixer{}
+	annotations = map[string]string{hostnameAnnotationKey: "foo.example.org"}
+	translated, ok := p.translateAnnotations(annotations)
+	assert.False(t, ok)
+	assert.Equal(t, annotations, translated)
+}
+
+func TestCheckSetIdentifier(t *testing.T) {
+	for _, tc := range []struct {
+		title            string
+		providerSpecific endpoint.ProviderSpecific
+		setIdentifier    string
+		expectError      bool
+	}{
+		{"no properties", nil, "", false},
+		{"no routing policy", endpoint.ProviderSpecific{{Name: "aws/evaluate-target-health", Value: "true"}}, "", false},
+		{"weight without set identifier", endpoint.ProviderSpecific{{Name: "aws/weight", Value: "10"}}, "", true},
+		{"failover without set identifier", endpoint.ProviderSpecific{{Name: "aws/failover", Value: "PRIMARY"}}, "", true},
+		{"weight with set identifier", endpoint.ProviderSpecific{{Name: "aws/weight", Value: "10"}}, "blue", false},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := checkSetIdentifier(tc.providerSpecific, tc.setIdentifier)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWaitForCacheSync(t *testing.T) {
+	err := waitForCacheSync("contour-httpproxy", "HTTPProxy", 100*time.Millisecond, func() bool { return true })
+	assert.NoError(t, err)
+
+	err = waitForCacheSync("contour-httpproxy", "HTTPProxy", 100*time.Millisecond, func() bool { return false })
+	assert.EqualError(t, err, "failed to sync HTTPProxy cache of contour-httpproxy source within 100ms: timed out waiting for the condition")
+}
+
+func TestGetServiceWithRetries(t *testing.T) {
+	for _, tc := range []struct {
+		title         string
+		service       string
+		failures      int
+		retries       int
+		expectError   bool
+		expectedCalls int
+	}{
+		{
+			title:         "transient errors are retried",
+			service:       "lb",
+			failures:      2,
+			retries:       3,
+			expectedCalls: 3,
+		},
+		{
+			title:         "gives up after the retries",
+			service:       "lb",
+			failures:      2,
+			retries:       1,
+			expectError:   true,
+			expectedCalls: 2,
+		},
+		{
+			title:         "not found is not retried",
+			service:       "missing",
+			retries:       3,
+			expectError:   true,
+			expectedCalls: 1,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "lb"},
+			})
+			calls := 0
+			client.PrependReactor("get", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if calls <= tc.failures {
+					return true, nil, apierrors.NewServiceUnavailable("unavailable")
+				}
+				return false, nil, nil
+			})
+
+			svc, err := getServiceWithRetries(context.Background(), client, "default", tc.service, tc.retries)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "lb", svc.Name)
+			}
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestParseTemplateFunctions(t *testing.T) {
+	for _, tc := range []struct {
+		fqdnTemplate string
+		expected     string
+	}{
+		{fqdnTemplate: "{{ lower .Name }}.example.org", expected: "my-app-svc.example.org"},
+		{fqdnTemplate: "{{ upper .Namespace }}.example.org", expected: "DEFAULT.example.org"},
+		{fqdnTemplate: `{{ trimSuffix (trimPrefix (lower .Name) "my-") "-svc" }}.example.org`, expected: "app.example.org"},
+		{fqdnTemplate: `{{ .Name | lower | replace "-" "." }}.example.org`, expected: "my.app.svc.example.org"},
+		{fqdnTemplate: `{{ .ClusterName | default "local" }}.example.org`, expected: "local.example.org"},
+	} {
+		t.Run(tc.fqdnTemplate, func(t *testing.T) {
+			tmpl, err := parseTemplate(tc.fqdnTemplate)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = tmpl.Execute(&buf, &metav1.ObjectMeta{Namespace: "default", Name: "My-App-Svc"})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	tmpl, err := parseTemplate("")
+	assert.NoError(t, err)
+	assert.Nil(t, tmpl, "should not parse an empty template")
+
+	_, err = parseTemplate("{{ unknown .Name }}")
+	assert.Error(t, err, "should not parse unknown functions")
+}
+
+func TestInformerHealth(t *testing.T) {
+	synced := false
+	health := newInformerHealth("node", func() bool { return true }, func() bool { return synced })
+
+	src := NewDedupSource(NewMultiSource([]Source{&struct {
+		Source
+		*informerHealth
+	}{informerHealth: health}}))
+
+	assert.EqualError(t, health.Healthy(), "informer caches of node source have not synced")
+	assert.Error(t, CheckHealth(src), "wrapped sources should report an unhealthy source")
+
+	synced = true
+	assert.NoError(t, health.Healthy())
+	assert.NoError(t, CheckHealth(src))
+}
+
+func TestCheckHealthWithoutHealthChecker(t *testing.T) {
+	assert.NoError(t, CheckHealth(new(testutils.MockSource)), "sources which can't report their health should be healthy")
+}
+
+func TestEndpointsWithWarningsWithoutReporter(t *testing.T) {
+	mockSource := new(testutils.MockSource)
+	mockSource.On("Endpoints").Return([]*endpoint.Endpoint{{DNSName: "foo.example.org"}}, nil)
+
+	endpoints, warnings, err := EndpointsWithWarnings(context.Background(), mockSource)
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Empty(t, warnings, "sources which can't report warnings should report none")
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package source
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -27,42 +28,175 @@ import (
 	"github.com/linki/instrumented_http"
 	openshift "github.com/openshift/client-go/route/clientset/versioned"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 // ErrSourceNotFound is returned when a requested source doesn't exist.
 var ErrSourceNotFound = errors.New("source not found")
 
+// SourceFactory builds a Source from the shared config.
+type SourceFactory func(ClientGenerator, *Config) (Source, error)
+
+// builtinSources are the names of the sources built by BuildWithConfig itself.
+var builtinSources = []string{
+	"node",
+	"service",
+	"ingress",
+	"istio-gateway",
+	"istio-virtualservice",
+	"cloudfoundry",
+	"ambassador-host",
+	"contour-ingressroute",
+	"contour-httpproxy",
+	"traefik-ingressroute",
+	"openshift-route",
+	"fake",
+	"connector",
+	"file",
+	"crd",
+	"empty",
+	"skipper-routegroup",
+	"endpointslice",
+}
+
+var (
+	registeredSourcesMu sync.RWMutex
+	registeredSources   = map[string]SourceFactory{}
+)
+
+// RegisterSource registers a factory for an out-of-tree Source, which can then be built by name
+// like the built-in sources. It returns an error if the name is already taken.
+func RegisterSource(name string, factory SourceFactory) error {
+	if name == "" || factory == nil {
+		return errors.New("source registration requires a name and a factory")
+	}
+	for _, builtin := range builtinSources {
+		if name == builtin {
+			return errors.Errorf("source %s is built in", name)
+		}
+	}
+
+	registeredSourcesMu.Lock()
+	defer registeredSourcesMu.Unlock()
+	if _, ok := registeredSources[name]; ok {
+		return errors.Errorf("source %s is already registered", name)
+	}
+	registeredSources[name] = factory
+	return nil
+}
+
 // Config holds shared configuration options for all Sources.
 type Config struct {
-	Namespace                      string
-	AnnotationFilter               string
-	LabelFilter                    string
-	FQDNTemplate                   string
-	CombineFQDNAndAnnotation       bool
-	IgnoreHostnameAnnotation       bool
-	IgnoreIngressTLSSpec           bool
-	Compatibility                  string
-	PublishInternal                bool
-	PublishHostIP                  bool
-	AlwaysPublishNotReadyAddresses bool
-	ConnectorServer                string
-	CRDSourceAPIVersion            string
-	CRDSourceKind                  string
-	KubeConfig                     string
-	APIServerURL                   string
-	ServiceTypeFilter              []string
-	CFAPIEndpoint                  string
-	CFUsername                     string
-	CFPassword                     string
-	ContourLoadBalancerService     string
-	SkipperRouteGroupVersion       string
-	RequestTimeout                 time.Duration
+	Namespace                              string
+	AnnotationPrefix                       string
+	NamespaceFilter                        NamespaceFilter
+	AnnotationFilter                       string
+	LabelFilter                            string
+	FQDNTemplate                           string
+	CombineFQDNAndAnnotation               bool
+	IgnoreHostnameAnnotation               bool
+	HostnameAnnotationStrategy             string
+	IgnoreIngressTLSSpec                   bool
+	Compatibility                          string
+	PublishInternal                        bool
+	PublishHostIP                          bool
+	AlwaysPublishNotReadyAddresses         bool
+	ConnectorServer                        string
+	FileSourcePath                         string
+	CRDSourceAPIVersion                    string
+	CRDSourceKind                          string
+	KubeConfig                             string
+	APIServerURL                           string
+	ServiceTypeFilter                      []string
+	CFAPIEndpoint                          string
+	CFUsername                             string
+	CFPassword                             string
+	ContourLoadBalancerService             string
+	ContourAcceptConditions                bool
+	ContourPublishInvalid                  bool
+	ContourNodePortTargets                 bool
+	ContourNodeAddressType                 string
+	ContourRouteWeightsToDNS               bool
+	ContourFQDNSuffix                      string
+	ContourEmitWWWAlias                    bool
+	ContourIngressClass                    string
+	TraefikLoadBalancerService             string
+	SkipperRouteGroupVersion               string
+	RequestTimeout                         time.Duration
+	AmbassadorMergeServiceProviderSpecific bool
+	RecordTypeTTLOverrides                 map[string]endpoint.TTL
+	PreferObjectTTL                        bool
+	DefaultTTL                             time.Duration
+	MinTTL                                 time.Duration
+	CacheSyncTimeout                       time.Duration
+	TargetLookupRetries                    int
+	MergeDuplicateEndpoints                bool
+	EventDebounceInterval                  time.Duration
+	DomainFilter                           []string
+	ExcludeDomains                         []string
+	ManagedRecordTypes                     []string
+	StripEndpointLabels                    []string
+	CreatePTRHints                         bool
+	ClusterID                              string
+	MetricsRegisterer                      prometheus.Registerer
+}
+
+// Validate checks that the configuration holds the options required by the named sources.
+// Unknown source names are ignored. All problems found are returned as one aggregated error.
+func (cfg *Config) Validate(sourceNames []string) error {
+	var errs []error
+	for _, name := range sourceNames {
+		switch name {
+		case "contour-ingressroute":
+			if cfg.ContourLoadBalancerService == "" {
+				errs = append(errs, errors.New("source contour-ingressroute requires a contour load balancer service"))
+			} else if _, err := parseContourLoadBalancerServices(cfg.ContourLoadBalancerService, cfg.Namespace); err != nil {
+				errs = append(errs, errors.Wrap(err, "source contour-ingressroute"))
+			}
+		case "traefik-ingressroute":
+			if cfg.TraefikLoadBalancerService == "" {
+				errs = append(errs, errors.New("source traefik-ingressroute requires a traefik load balancer service"))
+			} else if _, _, err := parseTraefikLoadBalancerService(cfg.TraefikLoadBalancerService); err != nil {
+				errs = append(errs, errors.Wrap(err, "source traefik-ingressroute"))
+			}
+		case "connector":
+			if cfg.ConnectorServer == "" {
+				errs = append(errs, errors.New("source connector requires a connector server"))
+			}
+		case "file":
+			if cfg.FileSourcePath == "" {
+				errs = append(errs, errors.New("source file requires a file path"))
+			}
+		case "crd":
+			if cfg.CRDSourceAPIVersion == "" || cfg.CRDSourceKind == "" {
+				errs = append(errs, errors.New("source crd requires an API version and kind"))
+			}
+		}
+	}
+	if _, err := parseAnnotationPrefix(cfg.AnnotationPrefix); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateRecordTypeTTLOverrides(cfg.RecordTypeTTLOverrides); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.NamespaceFilter.IsConfigured() {
+		if cfg.Namespace != "" {
+			errs = append(errs, errors.New("a namespace filter requires watching all namespaces"))
+		}
+		if err := cfg.NamespaceFilter.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
 }
 
 // ClientGenerator provides clients
@@ -72,6 +206,7 @@ type ClientGenerator interface {
 	CloudFoundryClient(cfAPPEndpoint string, cfUsername string, cfPassword string) (*cfclient.Client, error)
 	DynamicKubernetesClient() (dynamic.Interface, error)
 	OpenShiftClient() (openshift.Interface, error)
+	TraefikClient() (dynamic.Interface, error)
 }
 
 // SingletonClientGenerator stores provider clients and guarantees that only one instance of client
@@ -152,41 +287,174 @@ func (p *SingletonClientGenerator) OpenShiftClient() (openshift.Interface, error
 	return p.openshiftClient, err
 }
 
+// TraefikClient generates a client for the Traefik custom resources if it was not created before.
+// The custom resources are read through the dynamic client.
+func (p *SingletonClientGenerator) TraefikClient() (dynamic.Interface, error) {
+	return p.DynamicKubernetesClient()
+}
+
+// NamedConfig pairs the name of a Source with the configuration to build it with.
+type NamedConfig struct {
+	Name   string
+	Config *Config
+}
+
 // ByNames returns multiple Sources given multiple names.
+// If any of the sources can't be built, an error aggregating all failures is returned.
 func ByNames(p ClientGenerator, names []string, cfg *Config) ([]Source, error) {
-	sources := []Source{}
+	configs := make([]NamedConfig, 0, len(names))
 	for _, name := range names {
-		source, err := BuildWithConfig(name, p, cfg)
+		configs = append(configs, NamedConfig{Name: name, Config: cfg})
+	}
+	return ByNamesWithConfigs(p, configs)
+}
+
+// ByNamesWithConfigs returns multiple Sources, each built with its own configuration.
+// The same source may be requested multiple times with distinct configurations.
+// The configurations are validated before any source is built.
+// If any of the sources can't be built, an error aggregating all failures is returned.
+func ByNamesWithConfigs(p ClientGenerator, configs []NamedConfig) ([]Source, error) {
+	var errs []error
+	for _, nc := range configs {
+		if err := nc.Config.Validate([]string{nc.Name}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	// The metrics are shared by all sources, so they are registered once with each registerer.
+	registered := make(map[prometheus.Registerer]bool)
+	for _, nc := range configs {
+		if r := nc.Config.MetricsRegisterer; r != nil && !registered[r] {
+			if err := registerMetrics(r); err != nil {
+				return nil, err
+			}
+			registered[r] = true
+		}
+	}
+
+	sources := []Source{}
+	for _, nc := range configs {
+		source, err := buildWithPostProcessing(nc.Name, p, nc.Config)
+		if errors.Is(err, ErrSourceNotFound) {
+			errs = append(errs, fmt.Errorf("%w: %s", err, nc.Name))
+			continue
+		}
 		if err != nil {
-			return nil, err
+			errs = append(errs, errors.Wrapf(err, "failed to build source %s", nc.Name))
+			continue
 		}
 		sources = append(sources, source)
 	}
+	if len(errs) > 0 {
+		closeSources(sources)
+		return nil, utilerrors.NewAggregate(errs)
+	}
 
 	return sources, nil
 }
 
+// closeSources releases the informers of sources which were built before another one failed.
+func closeSources(sources []Source) {
+	for _, source := range sources {
+		if err := closeSource(source); err != nil {
+			log.Warnf("Failed to close source: %v", err)
+		}
+	}
+}
+
+// buildWithPostProcessing builds the named Source and wraps it with the endpoint
+// post-processing requested by the configuration.
+func buildWithPostProcessing(name string, p ClientGenerator, cfg *Config) (Source, error) {
+	source, err := BuildWithConfig(name, p, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var modifiers []EndpointModifier
+	if cfg.NamespaceFilter.IsConfigured() {
+		modifiers = append(modifiers, FilterNamespaces(cfg.NamespaceFilter))
+	}
+	if len(cfg.DomainFilter) > 0 || len(cfg.ExcludeDomains) > 0 {
+		modifiers = append(modifiers, FilterDomains(endpoint.NewDomainFilterWithExclusions(cfg.DomainFilter, cfg.ExcludeDomains)))
+	}
+	if len(cfg.ManagedRecordTypes) > 0 {
+		modifiers = append(modifiers, FilterRecordTypes(cfg.ManagedRecordTypes))
+	}
+	if cfg.MergeDuplicateEndpoints {
+		modifiers = append(modifiers, MergeEndpointsByNameType)
+	}
+	if len(cfg.RecordTypeTTLOverrides) > 0 {
+		modifiers = append(modifiers, OverrideRecordTypeTTLs(cfg.RecordTypeTTLOverrides, cfg.PreferObjectTTL))
+	}
+	if defaultTTL := endpoint.TTL(cfg.DefaultTTL.Seconds()); defaultTTL.IsConfigured() {
+		modifiers = append(modifiers, SetDefaultTTL(defaultTTL))
+	}
+	if minTTL := endpoint.TTL(cfg.MinTTL.Seconds()); minTTL.IsConfigured() {
+		modifiers = append(modifiers, EnforceMinTTL(minTTL))
+	}
+	if cfg.ClusterID != "" {
+		modifiers = append(modifiers, LabelCluster(cfg.ClusterID))
+	}
+	if cfg.CreatePTRHints {
+		modifiers = append(modifiers, LabelPTRHints())
+	}
+	if len(cfg.StripEndpointLabels) > 0 {
+		modifiers = append(modifiers, StripLabels(cfg.StripEndpointLabels))
+	}
+	if len(modifiers) > 0 {
+		source = NewModifiedSource(source, modifiers...)
+	}
+	if cfg.EventDebounceInterval > 0 {
+		source = NewDebounceSource(source, cfg.EventDebounceInterval)
+	}
+	return source, nil
+}
+
 // BuildWithConfig allows to generate a Source implementation from the shared config
 func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, error) {
+	src, err := buildSource(source, p, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Sources reading annotations use the configured prefix for their keys.
+	if setter, ok := src.(annotationPrefixSetter); ok {
+		if err := setter.setAnnotationPrefix(cfg.AnnotationPrefix); err != nil {
+			closeSources([]Source{src})
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// buildSource builds the named Source from the shared config.
+func buildSource(source string, p ClientGenerator, cfg *Config) (Source, error) {
 	switch source {
 	case "node":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewNodeSource(client, cfg.AnnotationFilter, cfg.FQDNTemplate)
+		return NewNodeSource(client, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CacheSyncTimeout)
 	case "service":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewServiceSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal, cfg.PublishHostIP, cfg.AlwaysPublishNotReadyAddresses, cfg.ServiceTypeFilter, cfg.IgnoreHostnameAnnotation)
+		return NewServiceSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal, cfg.PublishHostIP, cfg.AlwaysPublishNotReadyAddresses, cfg.ServiceTypeFilter, cfg.IgnoreHostnameAnnotation, cfg.CacheSyncTimeout)
+	case "endpointslice":
+		client, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewEndpointSliceSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.IgnoreHostnameAnnotation, cfg.CacheSyncTimeout)
 	case "ingress":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewIngressSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.IgnoreIngressTLSSpec)
+		return NewIngressSource(client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.IgnoreIngressTLSSpec, cfg.CacheSyncTimeout)
 	case "istio-gateway":
 		kubernetesClient, err := p.KubeClient()
 		if err != nil {
@@ -196,7 +464,7 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		if err != nil {
 			return nil, err
 		}
-		return NewIstioGatewaySource(kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewIstioGatewaySource(kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.CacheSyncTimeout)
 	case "istio-virtualservice":
 		kubernetesClient, err := p.KubeClient()
 		if err != nil {
@@ -206,7 +474,7 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		if err != nil {
 			return nil, err
 		}
-		return NewIstioVirtualServiceSource(kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewIstioVirtualServiceSource(kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.CacheSyncTimeout)
 	case "cloudfoundry":
 		cfClient, err := p.CloudFoundryClient(cfg.CFAPIEndpoint, cfg.CFUsername, cfg.CFPassword)
 		if err != nil {
@@ -222,7 +490,7 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		if err != nil {
 			return nil, err
 		}
-		return NewAmbassadorHostSource(dynamicClient, kubernetesClient, cfg.Namespace)
+		return NewAmbassadorHostSource(dynamicClient, kubernetesClient, cfg.Namespace, cfg.AmbassadorMergeServiceProviderSpecific, cfg.TargetLookupRetries, cfg.CacheSyncTimeout)
 	case "contour-ingressroute":
 		kubernetesClient, err := p.KubeClient()
 		if err != nil {
@@ -232,23 +500,39 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		if err != nil {
 			return nil, err
 		}
-		return NewContourIngressRouteSource(dynamicClient, kubernetesClient, cfg.ContourLoadBalancerService, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewContourIngressRouteSource(dynamicClient, kubernetesClient, cfg)
 	case "contour-httpproxy":
+		kubernetesClient, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
 		dynamicClient, err := p.DynamicKubernetesClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewContourHTTPProxySource(dynamicClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewContourHTTPProxySource(dynamicClient, kubernetesClient, cfg)
+	case "traefik-ingressroute":
+		kubernetesClient, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		traefikClient, err := p.TraefikClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewTraefikIngressRouteSource(traefikClient, kubernetesClient, cfg.TraefikLoadBalancerService, cfg.Namespace, cfg.AnnotationFilter, cfg.IgnoreHostnameAnnotation, cfg.TargetLookupRetries, cfg.CacheSyncTimeout)
 	case "openshift-route":
 		ocpClient, err := p.OpenShiftClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewOcpRouteSource(ocpClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewOcpRouteSource(ocpClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.CacheSyncTimeout)
 	case "fake":
 		return NewFakeSource(cfg.FQDNTemplate)
 	case "connector":
 		return NewConnectorSource(cfg.ConnectorServer)
+	case "file":
+		return NewFileSource(cfg.FileSourcePath)
 	case "crd":
 		client, err := p.KubeClient()
 		if err != nil {
@@ -271,6 +555,13 @@ func BuildWithConfig(source string, p ClientGenerator, cfg *Config) (Source, err
 		}
 		return NewRouteGroupSource(cfg.RequestTimeout, token, tokenPath, apiServerURL, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.SkipperRouteGroupVersion, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
 	}
+
+	registeredSourcesMu.RLock()
+	factory, ok := registeredSources[source]
+	registeredSourcesMu.RUnlock()
+	if ok {
+		return factory(p, cfg)
+	}
 	return nil, ErrSourceNotFound
 }
 
@@ -17,17 +17,27 @@ limitations under the License.
 package source
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	cfclient "github.com/cloudfoundry-community/go-cfclient"
 	openshift "github.com/openshift/client-go/route/clientset/versioned"
+	contour "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 type MockClientGenerator struct {
@@ -37,6 +47,7 @@ type MockClientGenerator struct {
 	cloudFoundryClient      *cfclient.Client
 	dynamicKubernetesClient dynamic.Interface
 	openshiftClient         openshift.Interface
+	traefikClient           dynamic.Interface
 }
 
 func (m *MockClientGenerator) KubeClient() (kubernetes.Interface, error) {
@@ -84,6 +95,15 @@ func (m *MockClientGenerator) OpenShiftClient() (openshift.Interface, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockClientGenerator) TraefikClient() (dynamic.Interface, error) {
+	args := m.Called()
+	if args.Error(1) == nil {
+		m.traefikClient = args.Get(0).(dynamic.Interface)
+		return m.traefikClient, nil
+	}
+	return nil, args.Error(1)
+}
+
 type ByNamesTestSuite struct {
 	suite.Suite
 }
@@ -101,6 +121,76 @@ func (suite *ByNamesTestSuite) TestAllInitialized() {
 	suite.Len(sources, 6, "should generate all six sources")
 }
 
+func (suite *ByNamesTestSuite) TestTraefikIngressRoute() {
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
+	mockClientGenerator.On("TraefikClient").Return(newTraefikDynamicClient(suite.T()), nil)
+
+	sources, err := ByNames(mockClientGenerator, []string{"traefik-ingressroute"}, &Config{TraefikLoadBalancerService: "traefik/traefik"})
+	suite.NoError(err, "should not generate errors")
+	suite.Len(sources, 1, "should generate the traefik source")
+	suite.IsType(&traefikSource{}, sources[0], "should be a traefik source")
+}
+
+func (suite *ByNamesTestSuite) TestContourNamespaceFromConfig() {
+	fakeDynamic, scheme := newDynamicKubernetesClient()
+	for _, namespace := range []string{"testing1", "testing2"} {
+		annotations := map[string]string{targetAnnotationKey: "1.2.3.4"}
+
+		ir, err := convertIngressRouteToUnstructured(fakeIngressRoute{
+			namespace:   namespace,
+			name:        "foo",
+			host:        "ingressroute." + namespace + ".example.org",
+			annotations: annotations,
+		}.IngressRoute(), scheme)
+		suite.Require().NoError(err)
+		_, err = fakeDynamic.Resource(contour.IngressRouteGVR).Namespace(namespace).Create(context.Background(), ir, metav1.CreateOptions{})
+		suite.Require().NoError(err)
+
+		hp, err := convertHTTPProxyToUnstructured(fakeHTTPProxy{
+			namespace:   namespace,
+			name:        "foo",
+			host:        "httpproxy." + namespace + ".example.org",
+			annotations: annotations,
+		}.HTTPProxy(), scheme)
+		suite.Require().NoError(err)
+		_, err = fakeDynamic.Resource(projectcontour.HTTPProxyGVR).Namespace(namespace).Create(context.Background(), hp, metav1.CreateOptions{})
+		suite.Require().NoError(err)
+	}
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
+	mockClientGenerator.On("DynamicKubernetesClient").Return(fakeDynamic, nil)
+
+	cfg := &Config{
+		Namespace:                  "testing1",
+		ContourLoadBalancerService: "heptio-contour/contour",
+	}
+	sources, err := ByNames(mockClientGenerator, []string{"contour-ingressroute", "contour-httpproxy"}, cfg)
+	suite.NoError(err, "should not generate errors")
+	suite.Require().Len(sources, 2, "should generate both contour sources")
+
+	for i, hostname := range []string{"ingressroute.testing1.example.org", "httpproxy.testing1.example.org"} {
+		endpoints, err := sources[i].Endpoints(context.Background())
+		suite.NoError(err)
+		validateEndpoints(suite.T(), endpoints, []*endpoint.Endpoint{
+			{DNSName: hostname, Targets: endpoint.Targets{"1.2.3.4"}},
+		})
+	}
+}
+
+func (suite *ByNamesTestSuite) TestRecordTypeTTLOverrides() {
+	mockClientGenerator := new(MockClientGenerator)
+
+	sources, err := ByNames(mockClientGenerator, []string{"fake"}, &Config{RecordTypeTTLOverrides: map[string]endpoint.TTL{endpoint.RecordTypeAAAA: 60}})
+	suite.NoError(err, "should not generate errors")
+	suite.Len(sources, 1, "should generate fake source")
+	suite.IsType(&modifiedSource{}, sources[0], "should wrap the source with the TTL overrides")
+
+	_, err = ByNames(mockClientGenerator, []string{"fake"}, &Config{RecordTypeTTLOverrides: map[string]endpoint.TTL{endpoint.RecordTypeAAAA: 0}})
+	suite.Error(err, "should return an error if a TTL override is invalid")
+}
+
 func (suite *ByNamesTestSuite) TestOnlyFake() {
 	mockClientGenerator := new(MockClientGenerator)
 	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
@@ -116,10 +206,33 @@ func (suite *ByNamesTestSuite) TestSourceNotFound() {
 	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
 
 	sources, err := ByNames(mockClientGenerator, []string{"foo"}, minimalConfig)
-	suite.Equal(err, ErrSourceNotFound, "should return source not found")
+	suite.True(errors.Is(err, ErrSourceNotFound), "should return source not found")
+	suite.Contains(err.Error(), "foo", "should name the source which was not found")
 	suite.Len(sources, 0, "should not returns any source")
 }
 
+func (suite *ByNamesTestSuite) TestRegisteredSource() {
+	mockClientGenerator := new(MockClientGenerator)
+	custom := NewEmptySource()
+
+	err := RegisterSource("custom-registered", func(p ClientGenerator, cfg *Config) (Source, error) {
+		suite.Equal(mockClientGenerator, p, "should pass the client generator")
+		return custom, nil
+	})
+	suite.NoError(err, "should register the source")
+
+	sources, err := ByNames(mockClientGenerator, []string{"custom-registered"}, minimalConfig)
+	suite.NoError(err, "should not generate errors")
+	suite.Equal([]Source{custom}, sources, "should build the registered source")
+
+	err = RegisterSource("custom-registered", func(ClientGenerator, *Config) (Source, error) { return nil, nil })
+	suite.Error(err, "should not register a source twice")
+	err = RegisterSource("service", func(ClientGenerator, *Config) (Source, error) { return nil, nil })
+	suite.Error(err, "should not override a built-in source")
+	err = RegisterSource("custom-nil", nil)
+	suite.Error(err, "should not register a source without a factory")
+}
+
 func (suite *ByNamesTestSuite) TestKubeClientFails() {
 	mockClientGenerator := new(MockClientGenerator)
 	mockClientGenerator.On("KubeClient").Return(nil, errors.New("foo"))
@@ -130,11 +243,17 @@ func (suite *ByNamesTestSuite) TestKubeClientFails() {
 	_, err = ByNames(mockClientGenerator, []string{"ingress"}, minimalConfig)
 	suite.Error(err, "should return an error if kubernetes client cannot be created")
 
+	_, err = ByNames(mockClientGenerator, []string{"endpointslice"}, minimalConfig)
+	suite.Error(err, "should return an error if kubernetes client cannot be created")
+
 	_, err = ByNames(mockClientGenerator, []string{"istio-gateway"}, minimalConfig)
 	suite.Error(err, "should return an error if kubernetes client cannot be created")
 
 	_, err = ByNames(mockClientGenerator, []string{"contour-ingressroute"}, minimalConfig)
 	suite.Error(err, "should return an error if kubernetes client cannot be created")
+
+	_, err = ByNames(mockClientGenerator, []string{"contour-httpproxy"}, minimalConfig)
+	suite.Error(err, "should return an error if kubernetes client cannot be created")
 }
 
 func (suite *ByNamesTestSuite) TestIstioClientFails() {
@@ -152,6 +271,217 @@ func (suite *ByNamesTestSuite) TestIstioClientFails() {
 	suite.Error(err, "should return an error if contour client cannot be created")
 }
 
+func (suite *ByNamesTestSuite) TestAggregatesErrors() {
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(fakeKube.NewSimpleClientset(), nil)
+	mockClientGenerator.On("IstioClient").Return(nil, errors.New("istio failure"))
+	mockClientGenerator.On("DynamicKubernetesClient").Return(nil, errors.New("dynamic failure"))
+
+	sources, err := ByNames(mockClientGenerator, []string{"istio-gateway", "fake", "contour-httpproxy"}, minimalConfig)
+	suite.Error(err, "should return an error if any source cannot be built")
+	suite.Nil(sources, "should not return any source")
+	suite.Contains(err.Error(), "failed to build source istio-gateway: istio failure")
+	suite.Contains(err.Error(), "failed to build source contour-httpproxy: dynamic failure")
+
+	_, err = ByNames(mockClientGenerator, []string{"istio-gateway", "foo"}, minimalConfig)
+	suite.True(errors.Is(err, ErrSourceNotFound), "should return source not found")
+	suite.Contains(err.Error(), "failed to build source istio-gateway: istio failure", "should keep the other errors")
+}
+
+func (suite *ByNamesTestSuite) TestForwardsWarnings() {
+	mockClientGenerator := new(MockClientGenerator)
+	reporter := &warningSource{
+		Source:    NewEmptySource(),
+		endpoints: []*endpoint.Endpoint{{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}}},
+		warnings:  []Warning{{Resource: "HTTPProxy/default/bar", Reason: skipReasonNoEndpoints, Message: "no targets"}},
+	}
+	suite.NoError(RegisterSource("custom-warnings", func(ClientGenerator, *Config) (Source, error) {
+		return reporter, nil
+	}))
+
+	sources, err := ByNames(mockClientGenerator, []string{"custom-warnings"}, &Config{
+		DefaultTTL:            time.Minute,
+		EventDebounceInterval: time.Second,
+	})
+	suite.NoError(err, "should not generate errors")
+
+	endpoints, warnings, err := EndpointsWithWarnings(context.Background(), NewDedupSource(NewMultiSource(sources)))
+	suite.NoError(err, "should return endpoints")
+	suite.Len(endpoints, 1, "should return the endpoints of the source")
+	suite.Equal(endpoint.TTL(60), endpoints[0].RecordTTL, "should post-process the endpoints")
+	suite.Equal(reporter.warnings, warnings, "should forward the warnings through all wrappers")
+}
+
+func (suite *ByNamesTestSuite) TestSourceNotFoundClosesBuiltSources() {
+	mockClientGenerator := new(MockClientGenerator)
+	closable := &closableSource{Source: NewEmptySource()}
+	suite.NoError(RegisterSource("custom-closable", func(ClientGenerator, *Config) (Source, error) {
+		return closable, nil
+	}))
+
+	sources, err := ByNames(mockClientGenerator, []string{"custom-closable", "foo"}, minimalConfig)
+	suite.True(errors.Is(err, ErrSourceNotFound), "should return source not found")
+	suite.Nil(sources, "should not return any source")
+	suite.True(closable.closed, "should close the sources built before the unknown one")
+}
+
+// closableSource records whether it was closed.
+type closableSource struct {
+	Source
+	closed bool
+}
+
+func (s *closableSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (suite *ByNamesTestSuite) TestSameSourceWithDistinctConfigs() {
+	mockClientGenerator := new(MockClientGenerator)
+
+	sources, err := ByNamesWithConfigs(mockClientGenerator, []NamedConfig{
+		{Name: "fake", Config: &Config{FQDNTemplate: "foo.example.org"}},
+		{Name: "fake", Config: &Config{FQDNTemplate: "bar.example.org"}},
+	})
+	suite.NoError(err, "should not generate errors")
+	suite.Len(sources, 2, "should generate both fake sources")
+
+	for i, domain := range []string{"foo.example.org", "bar.example.org"} {
+		endpoints, err := sources[i].Endpoints(context.Background())
+		suite.NoError(err, "should return endpoints")
+		suite.NotEmpty(endpoints, "should return endpoints")
+		for _, ep := range endpoints {
+			suite.True(strings.HasSuffix(ep.DNSName, "."+domain), "should use the fqdn template of its own config")
+		}
+	}
+}
+
+func (suite *ByNamesTestSuite) TestSameSourceWithDistinctAnnotationPrefixes() {
+	kubeClient := fakeKube.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "foo",
+			Annotations: map[string]string{
+				"dns.example.com/hostname": "foo.example.org",
+				"dns.example.net/hostname": "bar.example.org",
+				hostnameAnnotationKey:      "baz.example.org",
+			},
+		},
+		Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}},
+		},
+	})
+
+	mockClientGenerator := new(MockClientGenerator)
+	mockClientGenerator.On("KubeClient").Return(kubeClient, nil)
+
+	sources, err := ByNamesWithConfigs(mockClientGenerator, []NamedConfig{
+		{Name: "service", Config: &Config{AnnotationPrefix: "dns.example.com"}},
+		{Name: "service", Config: &Config{AnnotationPrefix: "dns.example.net"}},
+		{Name: "service", Config: &Config{}},
+	})
+	suite.NoError(err, "should not generate errors")
+	suite.Len(sources, 3, "should generate all service sources")
+
+	for i, hostname := range []string{"foo.example.org", "bar.example.org", "baz.example.org"} {
+		endpoints, err := sources[i].Endpoints(context.Background())
+		suite.NoError(err, "should return endpoints")
+		suite.Len(endpoints, 1, "should only read the annotations of its own prefix")
+		suite.Equal(hostname, endpoints[0].DNSName, "should only read the annotations of its own prefix")
+	}
+}
+
+func (suite *ByNamesTestSuite) TestInvalidConfig() {
+	mockClientGenerator := new(MockClientGenerator)
+
+	sources, err := ByNames(mockClientGenerator, []string{"fake", "contour-ingressroute"}, &Config{})
+	suite.EqualError(err, "source contour-ingressroute requires a contour load balancer service")
+	suite.Nil(sources, "should not return any source")
+	mockClientGenerator.AssertNotCalled(suite.T(), "DynamicKubernetesClient")
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		cfg         *Config
+		sources     []string
+		expectError bool
+	}{
+		{
+			title:       "missing contour load balancer service",
+			cfg:         &Config{},
+			sources:     []string{"service", "contour-ingressroute"},
+			expectError: true,
+		},
+		{
+			title:       "malformed contour load balancer service",
+			cfg:         &Config{ContourLoadBalancerService: "a/b/c"},
+			sources:     []string{"contour-ingressroute"},
+			expectError: true,
+		},
+		{
+			title:   "contour load balancer service",
+			cfg:     &Config{ContourLoadBalancerService: "heptio-contour/contour"},
+			sources: []string{"contour-ingressroute"},
+		},
+		{
+			title:   "missing contour load balancer service is not required",
+			cfg:     &Config{},
+			sources: []string{"service", "contour-httpproxy"},
+		},
+		{
+			title:       "missing crd kind",
+			cfg:         &Config{CRDSourceAPIVersion: "externaldns.k8s.io/v1alpha1"},
+			sources:     []string{"crd"},
+			expectError: true,
+		},
+		{
+			title:   "namespace filter",
+			cfg:     &Config{NamespaceFilter: NamespaceFilter{Deny: []string{"kube-system", "*-system"}}},
+			sources: []string{"service"},
+		},
+		{
+			title:       "namespace filter with a single namespace",
+			cfg:         &Config{Namespace: "default", NamespaceFilter: NamespaceFilter{Deny: []string{"kube-system"}}},
+			sources:     []string{"service"},
+			expectError: true,
+		},
+		{
+			title:   "record type TTL overrides",
+			cfg:     &Config{RecordTypeTTLOverrides: map[string]endpoint.TTL{endpoint.RecordTypeAAAA: 60}},
+			sources: []string{"service"},
+		},
+		{
+			title:       "record type TTL override of a lower case record type",
+			cfg:         &Config{RecordTypeTTLOverrides: map[string]endpoint.TTL{"aaaa": 60}},
+			sources:     []string{"service"},
+			expectError: true,
+		},
+		{
+			title:       "record type TTL override with an invalid TTL",
+			cfg:         &Config{RecordTypeTTLOverrides: map[string]endpoint.TTL{endpoint.RecordTypeAAAA: -1}},
+			sources:     []string{"service"},
+			expectError: true,
+		},
+		{
+			title:       "malformed namespace filter",
+			cfg:         &Config{NamespaceFilter: NamespaceFilter{Allow: []string{"team-["}}},
+			sources:     []string{"service"},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.cfg.Validate(tc.sources)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestByNames(t *testing.T) {
 	suite.Run(t, new(ByNamesTestSuite))
 }
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+func TestStripLabelsSource(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		keys     []string
+		expected []endpoint.Labels
+	}{
+		{
+			title: "no keys",
+			expected: []endpoint.Labels{
+				{endpoint.ResourceLabelKey: "service/default/foo", endpoint.OwnerIDLabelKey: "team-a"},
+				{endpoint.ResourceLabelKey: "service/default/bar"},
+			},
+		},
+		{
+			title: "owner id",
+			keys:  []string{endpoint.OwnerIDLabelKey},
+			expected: []endpoint.Labels{
+				{endpoint.ResourceLabelKey: "service/default/foo"},
+				{endpoint.ResourceLabelKey: "service/default/bar"},
+			},
+		},
+		{
+			title: "resource and unknown key",
+			keys:  []string{endpoint.ResourceLabelKey, "unknown"},
+			expected: []endpoint.Labels{
+				{endpoint.OwnerIDLabelKey: "team-a"},
+				{},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return([]*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/default/foo", endpoint.OwnerIDLabelKey: "team-a"}},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"1.2.3.5"}, RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.ResourceLabelKey: "service/default/bar"}},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"1.2.3.6"}, RecordType: endpoint.RecordTypeA},
+			}, nil)
+
+			endpoints, err := NewModifiedSource(mockSource, StripLabels(tc.keys)).Endpoints(context.Background())
+			require.NoError(t, err)
+			require.Len(t, endpoints, 3)
+
+			assert.Equal(t, tc.expected[0], endpoints[0].Labels)
+			assert.Equal(t, tc.expected[1], endpoints[1].Labels)
+			assert.Empty(t, endpoints[2].Labels)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var traefikIngressRouteGVR = schema.GroupVersionResource{
+	Group:    "traefik.containo.us",
+	Version:  "v1alpha1",
+	Resource: "ingressroutes",
+}
+
+var (
+	// traefikHostMatcherRegex matches the Host matchers of the match rule of a Traefik route, e.g. Host(`a.com`, `b.com`).
+	traefikHostMatcherRegex = regexp.MustCompile(`\bHost\(([^)]*)\)`)
+	// traefikHostRegex matches the backquoted hostnames of a Host matcher.
+	traefikHostRegex = regexp.MustCompile("`([^`]+)`")
+)
+
+// traefikIngressRoute is the part of a Traefik IngressRoute which is relevant for endpoints.
+type traefikIngressRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec traefikIngressRouteSpec `json:"spec"`
+}
+
+type traefikIngressRouteSpec struct {
+	Routes []traefikRoute `json:"routes,omitempty"`
+}
+
+type traefikRoute struct {
+	Match string `json:"match"`
+}
+
+// traefikSource is an implementation of Source for Traefik IngressRoute objects.
+// The hostnames are read from the Host matchers of the routes of the IngressRoute.
+// Use targetAnnotationKey to explicitly set Endpoint.
+type traefikSource struct {
+	dynamicKubeClient          dynamic.Interface
+	kubeClient                 kubernetes.Interface
+	traefikLoadBalancerService string
+	namespace                  string
+	annotationFilter           string
+	ignoreHostnameAnnotation   bool
+	targetLookupRetries        int
+	ingressRouteInformer       informers.GenericInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
+}
+
+// NewTraefikIngressRouteSource creates a new traefikSource with the given config.
+func NewTraefikIngressRouteSource(
+	dynamicKubeClient dynamic.Interface,
+	kubeClient kubernetes.Interface,
+	traefikLoadBalancerService string,
+	namespace string,
+	annotationFilter string,
+	ignoreHostnameAnnotation bool,
+	targetLookupRetries int,
+	cacheSyncTimeout time.Duration,
+) (Source, error) {
+	if _, _, err := parseTraefikLoadBalancerService(traefikLoadBalancerService); err != nil {
+		return nil, err
+	}
+
+	// Use shared informer to listen for add/update/delete of ingressroutes in the specified namespace.
+	// Set resync period to 0, to prevent processing when nothing has changed.
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	ingressRouteInformer := informerFactory.ForResource(traefikIngressRouteGVR)
+
+	// Add default resource event handlers to properly initialize informer.
+	ingressRouteInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
+
+	// wait for the local cache to be populated.
+	err := waitForCacheSync("traefik-ingressroute", "IngressRoute", cacheSyncTimeout, ingressRouteInformer.Informer().HasSynced)
+	if err != nil {
+		stop.Close()
+		return nil, err
+	}
+
+	return &traefikSource{
+		stopper:                    stop,
+		informerHealth:             newInformerHealth("traefik-ingressroute", ingressRouteInformer.Informer().HasSynced),
+		dynamicKubeClient:          dynamicKubeClient,
+		kubeClient:                 kubeClient,
+		traefikLoadBalancerService: traefikLoadBalancerService,
+		namespace:                  namespace,
+		annotationFilter:           annotationFilter,
+		ignoreHostnameAnnotation:   ignoreHostnameAnnotation,
+		targetLookupRetries:        targetLookupRetries,
+		ingressRouteInformer:       ingressRouteInformer,
+	}, nil
+}
+
+// Endpoints returns endpoint objects for each host-target combination that should be processed.
+// Retrieves all Traefik ingressroute resources in the source's namespace(s).
+func (sc *traefikSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("traefik-ingressroute")
+
+	objs, err := sc.ingressRouteInformer.Lister().ByNamespace(sc.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := getLabelSelector(sc.annotationFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []*endpoint.Endpoint{}
+
+	for _, obj := range objs {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		unstructuredIR, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.New("could not convert")
+		}
+
+		ir := &traefikIngressRoute{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredIR.Object, ir); err != nil {
+			return nil, errors.Wrap(err, "failed to convert to Traefik IngressRoute")
+		}
+		ir.Annotations, _ = sc.translateAnnotations(ir.Annotations)
+
+		if !matchLabelSelector(selector, ir.Annotations) {
+			continue
+		}
+
+		// Check controller annotation to see if we are responsible.
+		controller, ok := ir.Annotations[controllerAnnotationKey]
+		if ok && controller != controllerAnnotationValue {
+			log.Debugf("Skipping Traefik ingressroute %s/%s because controller value does not match, found: %s, required: %s",
+				ir.Namespace, ir.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(ir.Annotations); err != nil {
+			log.Warnf("Skipping Traefik ingressroute %s/%s: %v", ir.Namespace, ir.Name, err)
+			metrics.skip(skipReasonInvalid)
+			continue
+		}
+
+		irEndpoints, err := sc.endpointsFromIngressRoute(ctx, ir)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(irEndpoints) == 0 {
+			log.Debugf("No endpoints could be generated from Traefik ingressroute %s/%s", ir.Namespace, ir.Name)
+			metrics.skip(skipReasonNoEndpoints)
+			continue
+		}
+
+		log.Debugf("Endpoints generated from Traefik ingressroute: %s/%s: %v", ir.Namespace, ir.Name, irEndpoints)
+		for _, ep := range irEndpoints {
+			ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("traefik-ingressroute/%s/%s", ir.Namespace, ir.Name)
+		}
+		setDualstackLabel(ir.Annotations, fmt.Sprintf("Traefik ingressroute %s/%s", ir.Namespace, ir.Name), irEndpoints)
+		setOwnerIDLabel(ir.Annotations, irEndpoints)
+		endpoints = append(endpoints, irEndpoints...)
+	}
+
+	for _, ep := range endpoints {
+		sort.Sort(ep.Targets)
+	}
+
+	metrics.publish(endpoints)
+	return endpoints, nil
+}
+
+// endpointsFromIngressRoute extracts the endpoints from a Traefik IngressRoute object.
+func (sc *traefikSource) endpointsFromIngressRoute(ctx context.Context, ingressRoute *traefikIngressRoute) ([]*endpoint.Endpoint, error) {
+	var endpoints []*endpoint.Endpoint
+
+	ttl, err := getTTLFromAnnotations(ingressRoute.Annotations)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	targets := getTargetsFromTargetAnnotation(ingressRoute.Annotations)
+
+	if len(targets) == 0 {
+		targets, err = sc.targetsFromTraefikLoadBalancer(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ingressRoute.Annotations)
+	recordType := getRecordTypeFromAnnotations(ingressRoute.Annotations)
+	if err := checkSetIdentifier(providerSpecific, setIdentifier); err != nil {
+		log.Warnf("Skipping Traefik ingressroute %s/%s: %v", ingressRoute.Namespace, ingressRoute.Name, err)
+		return nil, nil
+	}
+
+	// The same host may be matched by several routes.
+	hostnames := map[string]bool{}
+	var hostnameList []string
+	addHostname := func(hostname string) {
+		if !hostnames[hostname] {
+			hostnames[hostname] = true
+			hostnameList = append(hostnameList, hostname)
+		}
+	}
+	for _, route := range ingressRoute.Spec.Routes {
+		for _, hostname := range traefikHostsFromMatch(route.Match) {
+			addHostname(hostname)
+		}
+	}
+
+	// Skip endpoints if we do not want entries from annotations
+	if !sc.ignoreHostnameAnnotation {
+		for _, hostname := range getHostnamesFromAnnotations(ingressRoute.Annotations) {
+			addHostname(hostname)
+		}
+	}
+
+	for _, hostname := range hostnameList {
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
+	}
+
+	return endpoints, nil
+}
+
+func (sc *traefikSource) targetsFromTraefikLoadBalancer(ctx context.Context) (targets endpoint.Targets, err error) {
+	lbNamespace, lbName, err := parseTraefikLoadBalancerService(sc.traefikLoadBalancerService)
+	if err != nil {
+		return nil, err
+	}
+	if svc, err := getServiceWithRetries(ctx, sc.kubeClient, lbNamespace, lbName, sc.targetLookupRetries); err != nil {
+		log.Warn(err)
+	} else {
+		for _, lb := range svc.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				targets = append(targets, lb.IP)
+			}
+			if lb.Hostname != "" {
+				targets = append(targets, lb.Hostname)
+			}
+		}
+	}
+
+	return
+}
+
+func parseTraefikLoadBalancerService(service string) (namespace, name string, err error) {
+	parts := strings.Split(service, "/")
+	if len(parts) != 2 {
+		err = fmt.Errorf("invalid traefik load balancer service (namespace/name) found '%v'", service)
+	} else {
+		namespace, name = parts[0], parts[1]
+	}
+
+	return
+}
+
+// traefikHostsFromMatch returns the hostnames of the Host matchers in the match rule of a Traefik route.
+func traefikHostsFromMatch(match string) []string {
+	var hostnames []string
+	for _, matcher := range traefikHostMatcherRegex.FindAllStringSubmatch(match, -1) {
+		for _, host := range traefikHostRegex.FindAllStringSubmatch(matcher[1], -1) {
+			hostnames = append(hostnames, host[1])
+		}
+	}
+	return hostnames
+}
+
+func (sc *traefikSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for Traefik ingressroute")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	sc.ingressRouteInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				handler()
+			},
+			UpdateFunc: func(old interface{}, new interface{}) {
+				handler()
+			},
+			DeleteFunc: func(obj interface{}) {
+				handler()
+			},
+		},
+	)
+}
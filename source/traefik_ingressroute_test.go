@@ -0,0 +1,338 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that traefikSource is a Source.
+var _ Source = &traefikSource{}
+
+func TestTraefikIngressRoute(t *testing.T) {
+	t.Run("NewTraefikIngressRouteSource", testNewTraefikIngressRouteSource)
+	t.Run("Endpoints", testTraefikIngressRouteEndpoints)
+	t.Run("HostsFromMatch", testTraefikHostsFromMatch)
+	t.Run("DualstackLabel", testTraefikIngressRouteDualstackLabel)
+	t.Run("AddEventHandler", testTraefikIngressRouteAddEventHandler)
+}
+
+func testNewTraefikIngressRouteSource(t *testing.T) {
+	for _, ti := range []struct {
+		title         string
+		loadBalancer  string
+		expectedError string
+	}{
+		{
+			title:        "valid load balancer service",
+			loadBalancer: "traefik/traefik",
+		},
+		{
+			title:         "invalid load balancer service",
+			loadBalancer:  "traefik",
+			expectedError: "invalid traefik load balancer service (namespace/name) found 'traefik'",
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			_, err := NewTraefikIngressRouteSource(
+				newTraefikDynamicClient(t),
+				fakeKube.NewSimpleClientset(),
+				ti.loadBalancer,
+				"",
+				"",
+				false,
+				0,
+				0,
+			)
+			if ti.expectedError != "" {
+				assert.EqualError(t, err, ti.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func testTraefikIngressRouteEndpoints(t *testing.T) {
+	for _, ti := range []struct {
+		title                    string
+		annotationFilter         string
+		ignoreHostnameAnnotation bool
+		ingressRoutes            []*unstructured.Unstructured
+		expected                 []*endpoint.Endpoint
+	}{
+		{
+			title:         "no ingressroute",
+			ingressRoutes: nil,
+			expected:      []*endpoint.Endpoint{},
+		},
+		{
+			title: "host matchers of all routes",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", nil, "Host(`foo.example.org`) && PathPrefix(`/foo`)", "Host(`bar.example.org`, `baz.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "baz.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "host matched by several routes",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					hostnameAnnotationKey: "foo.example.org",
+				}, "Host(`foo.example.org`) && PathPrefix(`/foo`)", "Host(`foo.example.org`) && PathPrefix(`/bar`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "weight without set identifier",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					"external-dns.alpha.kubernetes.io/aws-weight": "10",
+				}, "Host(`foo.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title: "target annotation",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					targetAnnotationKey: "lb.example.com",
+					ttlAnnotationKey:    "60",
+				}, "Host(`foo.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"lb.example.com"}, RecordType: endpoint.RecordTypeCNAME, RecordTTL: 60},
+			},
+		},
+		{
+			title: "hostname annotation",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					hostnameAnnotationKey: "bar.example.org",
+				}, "Host(`foo.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "bar.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:                    "ignored hostname annotation",
+			ignoreHostnameAnnotation: true,
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					hostnameAnnotationKey: "bar.example.org",
+				}, "Host(`foo.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:            "annotation filter",
+			annotationFilter: "kubernetes.io/ingress.class=traefik",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					"kubernetes.io/ingress.class": "traefik",
+				}, "Host(`foo.example.org`)"),
+				fakeTraefikIngressRoute("bar", map[string]string{
+					"kubernetes.io/ingress.class": "nginx",
+				}, "Host(`bar.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"8.8.8.8"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title: "other controller",
+			ingressRoutes: []*unstructured.Unstructured{
+				fakeTraefikIngressRoute("foo", map[string]string{
+					controllerAnnotationKey: "other-controller",
+				}, "Host(`foo.example.org`)"),
+			},
+			expected: []*endpoint.Endpoint{},
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			fakeKubernetesClient := fakeKube.NewSimpleClientset()
+			lbService := fakeLoadBalancerService{
+				ips:       []string{"8.8.8.8"},
+				namespace: "traefik",
+				name:      "traefik",
+			}.Service()
+			_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			src, err := NewTraefikIngressRouteSource(
+				newTraefikDynamicClient(t, ti.ingressRoutes...),
+				fakeKubernetesClient,
+				"traefik/traefik",
+				"",
+				ti.annotationFilter,
+				ti.ignoreHostnameAnnotation,
+				0,
+				0,
+			)
+			require.NoError(t, err)
+
+			endpoints, err := src.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, ti.expected)
+			for _, ep := range endpoints {
+				assert.Contains(t, ep.Labels[endpoint.ResourceLabelKey], "traefik-ingressroute/default/")
+			}
+		})
+	}
+}
+
+func testTraefikHostsFromMatch(t *testing.T) {
+	for _, ti := range []struct {
+		match    string
+		expected []string
+	}{
+		{match: "Host(`foo.example.org`)", expected: []string{"foo.example.org"}},
+		{match: "Host(`foo.example.org`, `bar.example.org`)", expected: []string{"foo.example.org", "bar.example.org"}},
+		{match: "Host(`foo.example.org`) || Host(`bar.example.org`)", expected: []string{"foo.example.org", "bar.example.org"}},
+		{match: "PathPrefix(`/foo`) && Host(`foo.example.org`)", expected: []string{"foo.example.org"}},
+		{match: "HostRegexp(`{subdomain:[a-z]+}.example.org`)", expected: nil},
+		{match: "HostSNI(`foo.example.org`)", expected: nil},
+		{match: "PathPrefix(`/foo`)", expected: nil},
+	} {
+		t.Run(ti.match, func(t *testing.T) {
+			assert.Equal(t, ti.expected, traefikHostsFromMatch(ti.match))
+		})
+	}
+}
+
+func testTraefikIngressRouteDualstackLabel(t *testing.T) {
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	lbService := fakeLoadBalancerService{
+		hostnames: []string{"lb.example.com"},
+		namespace: "traefik",
+		name:      "traefik",
+	}.Service()
+	_, err := fakeKubernetesClient.CoreV1().Services(lbService.Namespace).Create(context.Background(), lbService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	src, err := NewTraefikIngressRouteSource(
+		newTraefikDynamicClient(t,
+			fakeTraefikIngressRoute("dualstack", map[string]string{ALBDualstackAnnotationKey: ALBDualstackAnnotationValue}, "Host(`foo.example.org`)"),
+			fakeTraefikIngressRoute("ipv4", map[string]string{ALBDualstackAnnotationKey: "ipv4"}, "Host(`bar.example.org`)"),
+		),
+		fakeKubernetesClient,
+		"traefik/traefik",
+		"",
+		"",
+		false,
+		0,
+		0,
+	)
+	require.NoError(t, err)
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	for _, ep := range endpoints {
+		if ep.DNSName == "foo.example.org" {
+			assert.Equal(t, "true", ep.Labels[endpoint.DualstackLabelKey])
+		} else {
+			assert.NotContains(t, ep.Labels, endpoint.DualstackLabelKey)
+		}
+	}
+}
+
+// testTraefikIngressRouteAddEventHandler tests that the handler is called once an ingressroute is added.
+func testTraefikIngressRouteAddEventHandler(t *testing.T) {
+	client := newTraefikDynamicClient(t)
+	src, err := NewTraefikIngressRouteSource(client, fakeKube.NewSimpleClientset(), "traefik/traefik", "", "", false, 0, 0)
+	require.NoError(t, err)
+	defer src.(*traefikSource).Close()
+
+	called := make(chan struct{}, 1)
+	src.AddEventHandler(context.Background(), func() {
+		select {
+		case called <- struct{}{}:
+		default:
+		}
+	})
+
+	ir := fakeTraefikIngressRoute("foo", nil, "Host(`foo.example.org`)")
+	_, err = client.Resource(traefikIngressRouteGVR).Namespace(ir.GetNamespace()).Create(context.Background(), ir, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler should be called once an ingressroute is added")
+	}
+}
+
+func newTraefikDynamicClient(t *testing.T, ingressRoutes ...*unstructured.Unstructured) *fakeDynamic.FakeDynamicClient {
+	s := runtime.NewScheme()
+	gv := traefikIngressRouteGVR.GroupVersion()
+	s.AddKnownTypeWithName(gv.WithKind("IngressRoute"), &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(gv.WithKind("IngressRouteList"), &unstructured.UnstructuredList{})
+	client := fakeDynamic.NewSimpleDynamicClient(s)
+
+	for _, ir := range ingressRoutes {
+		_, err := client.Resource(traefikIngressRouteGVR).Namespace(ir.GetNamespace()).Create(context.Background(), ir, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	return client
+}
+
+func fakeTraefikIngressRoute(name string, annotations map[string]string, matches ...string) *unstructured.Unstructured {
+	routes := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		routes = append(routes, map[string]interface{}{
+			"kind":  "Rule",
+			"match": match,
+		})
+	}
+
+	ir := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": traefikIngressRouteGVR.GroupVersion().String(),
+			"kind":       "IngressRoute",
+			"spec": map[string]interface{}{
+				"routes": routes,
+			},
+		},
+	}
+	ir.SetNamespace("default")
+	ir.SetName(name)
+	ir.SetAnnotations(annotations)
+	return ir
+}
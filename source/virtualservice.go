@@ -33,7 +33,6 @@ import (
 	networkingv1alpha3informer "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -58,6 +57,9 @@ type virtualServiceSource struct {
 	ignoreHostnameAnnotation bool
 	serviceInformer          coreinformers.ServiceInformer
 	virtualserviceInformer   networkingv1alpha3informer.VirtualServiceInformer
+	annotationPrefixer
+	*stopper
+	*informerHealth
 }
 
 // NewIstioVirtualServiceSource creates a new virtualServiceSource with the given config.
@@ -69,19 +71,16 @@ func NewIstioVirtualServiceSource(
 	fqdnTemplate string,
 	combineFQDNAnnotation bool,
 	ignoreHostnameAnnotation bool,
+	cacheSyncTimeout time.Duration,
 ) (Source, error) {
 	var (
 		tmpl *template.Template
 		err  error
 	)
 
-	if fqdnTemplate != "" {
-		tmpl, err = template.New("endpoint").Funcs(template.FuncMap{
-			"trimPrefix": strings.TrimPrefix,
-		}).Parse(fqdnTemplate)
-		if err != nil {
-			return nil, err
-		}
+	tmpl, err = parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use shared informers to listen for add/update/delete of services/pods/nodes in the specified namespace.
@@ -108,26 +107,28 @@ func NewIstioVirtualServiceSource(
 		},
 	)
 
-	// TODO informer is not explicitly stopped since controller is not passing in its channel.
-	informerFactory.Start(wait.NeverStop)
-	istioInformerFactory.Start(wait.NeverStop)
+	// The informers are stopped once the source is closed.
+	stop := newStopper()
+	informerFactory.Start(stop.stopCh)
+	istioInformerFactory.Start(stop.stopCh)
 
 	// wait for the local cache to be populated.
-	err = wait.Poll(time.Second, 60*time.Second, func() (bool, error) {
-		return serviceInformer.Informer().HasSynced(), nil
-	})
+	err = waitForCacheSync("istio-virtualservice", "Service", cacheSyncTimeout, serviceInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
-	err = wait.Poll(time.Second, 60*time.Second, func() (bool, error) {
-		return virtualServiceInformer.Informer().HasSynced(), nil
-	})
+	// wait for the local cache to be populated.
+	err = waitForCacheSync("istio-virtualservice", "VirtualService", cacheSyncTimeout, virtualServiceInformer.Informer().HasSynced)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sync cache: %v", err)
+		stop.Close()
+		return nil, err
 	}
 
 	return &virtualServiceSource{
+		stopper:                  stop,
+		informerHealth:           newInformerHealth("istio-virtualservice", serviceInformer.Informer().HasSynced, virtualServiceInformer.Informer().HasSynced),
 		kubeClient:               kubeClient,
 		istioClient:              istioClient,
 		namespace:                namespace,
@@ -143,12 +144,19 @@ func NewIstioVirtualServiceSource(
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all VirtualService resources in the source's namespace(s).
 func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	metrics := newSourceMetrics("istio-virtualservice")
+
 	virtualServiceList, err := sc.istioClient.NetworkingV1alpha3().VirtualServices(sc.namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	virtualServices := virtualServiceList.Items
+	for i := range virtualServices {
+		if annotations, ok := sc.translateAnnotations(virtualServices[i].Annotations); ok {
+			virtualServices[i].Annotations = annotations
+		}
+	}
 	virtualServices, err = sc.filterByAnnotations(virtualServices)
 	if err != nil {
 		return nil, err
@@ -162,6 +170,12 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 		if ok && controller != controllerAnnotationValue {
 			log.Debugf("Skipping VirtualService %s/%s because controller value does not match, found: %s, required: %s",
 				virtualService.Namespace, virtualService.Name, controller, controllerAnnotationValue)
+			metrics.skip(skipReasonController)
+			continue
+		}
+		if err := checkTargetAnnotation(virtualService.Annotations); err != nil {
+			log.Warnf("Skipping VirtualService %s/%s: %v", virtualService.Namespace, virtualService.Name, err)
+			metrics.skip(skipReasonInvalid)
 			continue
 		}
 
@@ -186,11 +200,13 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 
 		if len(gwEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from VirtualService %s/%s", virtualService.Namespace, virtualService.Name)
+			metrics.skip(skipReasonNoEndpoints)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from VirtualService: %s/%s: %v", virtualService.Namespace, virtualService.Name, gwEndpoints)
 		sc.setResourceLabel(virtualService, gwEndpoints)
+		setOwnerIDLabel(virtualService.Annotations, gwEndpoints)
 		endpoints = append(endpoints, gwEndpoints...)
 	}
 
@@ -198,6 +214,7 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 		sort.Sort(ep.Targets)
 	}
 
+	metrics.publish(endpoints)
 	return endpoints, nil
 }
 
@@ -266,6 +283,7 @@ func (sc *virtualServiceSource) endpointsFromTemplate(ctx context.Context, virtu
 	var endpoints []*endpoint.Endpoint
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(virtualService.Annotations)
+	recordType := getRecordTypeFromAnnotations(virtualService.Annotations)
 
 	// splits the FQDN template and removes the trailing periods
 	hostnames := strings.Split(strings.Replace(hostnamesTemplate, " ", "", -1), ",")
@@ -275,7 +293,7 @@ func (sc *virtualServiceSource) endpointsFromTemplate(ctx context.Context, virtu
 		if err != nil {
 			return endpoints, err
 		}
-		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 	return endpoints, nil
 }
@@ -362,6 +380,7 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 	targetsFromAnnotation := getTargetsFromTargetAnnotation(virtualservice.Annotations)
 
 	providerSpecific, setIdentifier := getProviderSpecificAnnotations(virtualservice.Annotations)
+	recordType := getRecordTypeFromAnnotations(virtualservice.Annotations)
 
 	for _, host := range virtualservice.Spec.Hosts {
 		if host == "" || host == "*" {
@@ -384,7 +403,7 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 			}
 		}
 
-		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier)...)
+		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 	}
 
 	// Skip endpoints if we do not want entries from annotations
@@ -398,7 +417,7 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 					return endpoints, err
 				}
 			}
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier)...)
+			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, recordType)...)
 		}
 	}
 
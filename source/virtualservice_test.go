@@ -78,6 +78,7 @@ func (suite *VirtualServiceSuite) SetupTest() {
 		"{{.Name}}",
 		false,
 		false,
+		0,
 	)
 	suite.NoError(err, "should initialize virtualservice source")
 
@@ -164,6 +165,7 @@ func TestNewIstioVirtualServiceSource(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				false,
+				0,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -1471,6 +1473,7 @@ func testVirtualServiceEndpoints(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				ti.ignoreHostnameAnnotation,
+				0,
 			)
 			require.NoError(t, err)
 
@@ -1546,6 +1549,7 @@ func newTestVirtualServiceSource(loadBalancerList []fakeIngressGatewayService, g
 		"{{.Name}}",
 		false,
 		false,
+		0,
 	)
 	if err != nil {
 		return nil, err